@@ -15,8 +15,9 @@ func (g *Gmvc4HertzBuilder) Wrap(action gmvc.Action, mdw ...gmvc.IMiddleware) ap
 	return Wrap(g.BuildAction(action, mdw...))
 }
 
-func CreateGmvc4HertzBuilder() *Gmvc4HertzBuilder {
-	builder := gmvc.CreateGmvcBuilder(gmvc.DefineAuto(gmvc.QuerySrc, gmvc.FormSrc))
+// CreateGmvc4HertzBuilder creates the builder for hertz with the options, eg. gmvc.Negotiate.
+func CreateGmvc4HertzBuilder(options ...gmvc.GmvcOption) *Gmvc4HertzBuilder {
+	builder := gmvc.CreateGmvcBuilder(options...)
 	return &Gmvc4HertzBuilder{
 		GmvcBuilder: builder,
 	}
@@ -3,11 +3,15 @@ package gmvc_hertz
 import (
 	"context"
 	"io"
+	"net/http"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network"
 	"github.com/cloudwego/hertz/pkg/protocol"
+	http1resp "github.com/cloudwego/hertz/pkg/protocol/http1/resp"
 	"github.com/zhengrenjie/gmvc"
 )
 
@@ -21,6 +25,7 @@ func AcquireHertzContext(ctx context.Context, hertzCtx *app.RequestContext) *Her
 		c:        ctx,
 		ctx:      hertzCtx,
 		paramSet: make(map[string]interface{}, 0),
+		present:  make(map[string]interface{}, 0),
 
 		requset:  AcquireHertzReqAdapter(hertzCtx),
 		response: AcquireHertzRespAdapter(hertzCtx),
@@ -33,6 +38,7 @@ type HertzContext struct {
 	c        context.Context
 	ctx      *app.RequestContext
 	paramSet map[string]interface{}
+	present  map[string]interface{}
 
 	requset  *hertzReqAdapter
 	response *hertzRespAdapter
@@ -171,6 +177,15 @@ func (h *HertzContext) Report(name string) {
 	h.paramSet[name] = silence
 }
 
+func (h *HertzContext) Present(field string) bool {
+	_, ok := h.present[field]
+	return ok
+}
+
+func (h *HertzContext) MarkPresent(field string) {
+	h.present[field] = silence
+}
+
 func (h *HertzContext) GetStatus() int {
 	return h.ctx.Response.StatusCode()
 }
@@ -238,6 +253,22 @@ type (
 	hertzRespAdapter struct {
 		hertzCtx *app.RequestContext
 		header   *hertzRespHeaderAdapter
+
+		// body stream set by gmvc, counting the bytes read by hertz
+		stream *countingReader
+
+		// chunked writer hijacking the response, see Writer
+		writer *chunkedWriter
+	}
+
+	countingReader struct {
+		reader io.Reader
+		count  int64
+	}
+
+	chunkedWriter struct {
+		writer network.ExtWriter
+		count  int64
 	}
 
 	hertzReqHeaderAdapter struct {
@@ -275,8 +306,82 @@ func (h *hertzRespHeaderAdapter) VisitAll(f func(k []byte, v []byte)) {
 }
 
 // Body implements gmvc.HttpResponse.
+// The body is sent with Content-Length if out reports its remaining length, eg. *bytes.Reader, otherwise chunked.
 func (h *hertzRespAdapter) Body(out io.Reader) {
-	h.hertzCtx.SetBodyStream(out, -1)
+	size := -1
+	if sized, ok := out.(interface{ Len() int }); ok && sized.Len() >= 0 {
+		size = sized.Len()
+	}
+
+	h.stream = &countingReader{reader: out}
+	h.hertzCtx.SetBodyStream(h.stream, size)
+}
+
+// StatusCode implements gmvc.HttpResponse.
+func (h *hertzRespAdapter) StatusCode() int {
+	return h.hertzCtx.Response.StatusCode()
+}
+
+// Committed implements gmvc.HttpResponse.
+func (h *hertzRespAdapter) Committed() bool {
+	if h.stream != nil || h.writer != nil {
+		return true
+	}
+
+	// written through hertz.RequestContext directly
+	resp := &h.hertzCtx.Response
+	return resp.IsBodyStream() || len(resp.BodyBytes()) > 0 || resp.GetHijackWriter() != nil
+}
+
+// Written implements gmvc.HttpResponse.
+func (h *hertzRespAdapter) Written() int64 {
+	if h.stream != nil {
+		return atomic.LoadInt64(&h.stream.count)
+	}
+
+	if h.writer != nil {
+		return atomic.LoadInt64(&h.writer.count)
+	}
+
+	return int64(len(h.hertzCtx.Response.BodyBytes()))
+}
+
+// Writer implements gmvc.HttpResponse.
+// The response is hijacked by a chunked writer, hertz finalizes it after the handler returns.
+func (h *hertzRespAdapter) Writer() gmvc.ResponseWriter {
+	if h.writer == nil {
+		writer := http1resp.NewChunkedBodyWriter(&h.hertzCtx.Response, h.hertzCtx.GetWriter())
+		h.hertzCtx.Response.HijackWriter(writer)
+		h.writer = &chunkedWriter{writer: writer}
+	}
+
+	return h.writer
+}
+
+func (w *chunkedWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	atomic.AddInt64(&w.count, int64(n))
+	return n, err
+}
+
+// Flush sends the chunk to the client, the header is sent on the first flush.
+func (w *chunkedWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	atomic.AddInt64(&r.count, int64(n))
+	return n, err
+}
+
+// Close closes the underlying reader if it is an io.Closer, hertz closes the body stream after writing.
+func (r *countingReader) Close() error {
+	if closer, ok := r.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
 }
 
 // HTML implements gmvc.HttpResponse.
@@ -294,6 +399,12 @@ func (h *hertzRespAdapter) SetHeader(key string, value string) {
 	h.hertzCtx.Header(key, value)
 }
 
+// SetCookie implements gmvc.HttpResponse.
+// hertz keeps one Set-Cookie header per cookie name.
+func (h *hertzRespAdapter) SetCookie(cookie *http.Cookie) {
+	h.hertzCtx.Response.Header.Add("Set-Cookie", cookie.String())
+}
+
 // Status implements gmvc.HttpResponse.
 func (h *hertzRespAdapter) Status(code int) {
 	h.hertzCtx.Status(code)
@@ -339,6 +450,27 @@ func (adapter *hertzReqAdapter) GetForm(key string) (string, bool) {
 	return string(v), true
 }
 
+// GetFormAll implements gmvc.HttpRequest.
+// The same as GetForm, query args are looked up first, then post args and multipart form.
+func (adapter *hertzReqAdapter) GetFormAll(key string) ([]string, bool) {
+	if values := peekAll(adapter.hertzReq.URI().QueryArgs().PeekAll(key)); len(values) > 0 {
+		return values, true
+	}
+
+	if values := peekAll(adapter.hertzReq.PostArgs().PeekAll(key)); len(values) > 0 {
+		return values, true
+	}
+
+	form, err := adapter.hertzReq.MultipartForm()
+	if err == nil && form.Value != nil {
+		if values, ok := form.Value[key]; ok && len(values) > 0 {
+			return values, true
+		}
+	}
+
+	return nil, false
+}
+
 // GetPathParam implements gmvc.HttpRequest.
 func (adapter *hertzReqAdapter) GetPathParam(key string) (string, bool) {
 	return adapter.hertzCtx.Params.Get(key)
@@ -359,12 +491,55 @@ func (adapter *hertzReqAdapter) GetQuery(key string) (string, bool) {
 	return adapter.hertzCtx.GetQuery(key)
 }
 
+// GetQueryAll implements gmvc.HttpRequest.
+func (adapter *hertzReqAdapter) GetQueryAll(key string) ([]string, bool) {
+	values := peekAll(adapter.hertzReq.URI().QueryArgs().PeekAll(key))
+	return values, len(values) > 0
+}
+
+func peekAll(values [][]byte) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	ret := make([]string, 0, len(values))
+	for _, v := range values {
+		ret = append(ret, string(v))
+	}
+
+	return ret
+}
+
 func (adapter *hertzReqAdapter) VisitAllQuery(f func(key, value string)) {
 	adapter.hertzCtx.VisitAllQueryArgs(func(key, value []byte) {
 		f(string(key), string(value))
 	})
 }
 
+// GetCookie implements gmvc.HttpRequest.
+func (adapter *hertzReqAdapter) GetCookie(key string) (string, bool) {
+	value, found := "", false
+	adapter.hertzReq.Header.VisitAllCookie(func(k, v []byte) {
+		if !found && string(k) == key {
+			value, found = string(v), true
+		}
+	})
+
+	return value, found
+}
+
+func (adapter *hertzReqAdapter) VisitAllCookie(f func(key, value string)) {
+	adapter.hertzReq.Header.VisitAllCookie(func(key, value []byte) {
+		f(string(key), string(value))
+	})
+}
+
+func (adapter *hertzReqAdapter) VisitAllPathParam(f func(key, value string)) {
+	for _, param := range adapter.hertzCtx.Params {
+		f(param.Key, param.Value)
+	}
+}
+
 func (adapter *hertzReqAdapter) VisitAllPostForm(f func(key, value string)) {
 	adapter.hertzCtx.VisitAllPostArgs(func(key, value []byte) {
 		f(string(key), string(value))
@@ -0,0 +1,348 @@
+package gmvc
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PrincipalKey is the context key under which [AuthMiddleware] stores the authenticated [Principal].
+const PrincipalKey = "gmvc.principal"
+
+var (
+	// ErrUnauthorized means the request carries no valid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden means the principal is authenticated but not allowed to invoke the Action.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Principal is the authenticated identity of the current request.
+// It can be bound into Action fields by `param:"Principal"`.
+type Principal struct {
+	// Subject identifies the principal, eg. the user id or the api key owner.
+	Subject string
+
+	// Scheme is the authentication scheme which produced the principal, eg. "Bearer", "Basic", "ApiKey".
+	Scheme string
+
+	// Roles granted to the principal.
+	Roles []string
+
+	// Scopes granted to the principal.
+	Scopes []string
+
+	// Claims holds any extra attributes of the principal, eg. the JWT claims.
+	Claims map[string]any
+}
+
+// HasRole reports whether the principal has been granted the role.
+func (p *Principal) HasRole(role string) bool {
+	return contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal has been granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+// GetPrincipal returns the authenticated [Principal] of the request, or nil if it is anonymous.
+func GetPrincipal(ctx GmvcContext) *Principal {
+	v, ok := ctx.GetCtx(PrincipalKey)
+	if !ok {
+		return nil
+	}
+
+	p, _ := v.(*Principal)
+	return p
+}
+
+// Authenticator extracts and verifies the credentials of a request.
+// If the request does not carry the kind of credentials the Authenticator handles, it returns (nil, nil),
+// so that the next Authenticator can take a try.
+// If the credentials are present but invalid, it returns an error wrapping [ErrUnauthorized], [ErrInvalidToken]
+// or [ErrTokenExpired], or an [AuthError], which is answered with 401. Any other error, eg. the user store is down,
+// is returned as is and answered with 500.
+type Authenticator interface {
+	Authenticate(ctx GmvcContext) (*Principal, error)
+}
+
+// Challenger is optionally implemented by an [Authenticator] to provide the `WWW-Authenticate` challenge
+// returned along with a 401 response.
+type Challenger interface {
+	Challenge() string
+}
+
+// Secured is implemented by Actions which can only be invoked by an authenticated [Principal].
+// The requirements are checked after the parameters are resolved and before [Initializer.Init].
+type Secured interface {
+	// RequiredRoles returns the roles of which the principal must hold at least one.
+	// Empty means any authenticated principal is allowed.
+	RequiredRoles() []string
+
+	// RequiredScopes returns the scopes the principal must hold all of.
+	RequiredScopes() []string
+}
+
+// AuthError is returned when the authentication or authorization fails.
+type AuthError struct {
+	// Status is the HTTP status code, either 401 or 403.
+	Status int
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s: %v", http.StatusText(e.Status), e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// isCredentialError reports whether the error means the credentials are invalid, rather than the authentication failed.
+func isCredentialError(err error) bool {
+	return errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenExpired)
+}
+
+func unauthorized(err error) *AuthError {
+	return &AuthError{Status: http.StatusUnauthorized, Err: err}
+}
+
+func forbidden(err error) *AuthError {
+	return &AuthError{Status: http.StatusForbidden, Err: err}
+}
+
+var _ IMiddleware = (*AuthMiddleware)(nil)
+
+// AuthMiddleware runs the Authenticators in order and places the first [Principal] found into the context.
+type AuthMiddleware struct {
+	BaseMiddleware
+
+	// Authenticators are tried in order, the first one returning a principal wins.
+	Authenticators []Authenticator
+
+	// Optional allows requests without any credentials to go on anonymously.
+	// Requests with invalid credentials are still rejected.
+	Optional bool
+}
+
+// NewAuthMiddleware creates an [AuthMiddleware] which rejects anonymous requests.
+func NewAuthMiddleware(authenticators ...Authenticator) *AuthMiddleware {
+	return &AuthMiddleware{
+		Authenticators: authenticators,
+	}
+}
+
+// Before implements IMiddleware.
+func (m *AuthMiddleware) Before(ctx GmvcContext) (interface{}, error) {
+	for _, authenticator := range m.Authenticators {
+		principal, err := authenticator.Authenticate(ctx)
+		if err != nil {
+			// 只有凭证错误返回401，其他错误(例如数据库、密钥获取失败)原样返回，作为500处理
+			var authErr *AuthError
+			if errors.As(err, &authErr) {
+				m.challenge(ctx)
+				return nil, err
+			}
+
+			if !isCredentialError(err) {
+				return nil, err
+			}
+
+			m.challenge(ctx)
+			return nil, unauthorized(err)
+		}
+
+		if principal != nil {
+			ctx.Set(PrincipalKey, principal)
+			return nil, nil
+		}
+	}
+
+	if m.Optional {
+		return nil, nil
+	}
+
+	m.challenge(ctx)
+	return nil, unauthorized(ErrUnauthorized)
+}
+
+func (m *AuthMiddleware) challenge(ctx GmvcContext) {
+	challenges := make([]string, 0, len(m.Authenticators))
+	for _, authenticator := range m.Authenticators {
+		if c, ok := authenticator.(Challenger); ok {
+			challenges = append(challenges, c.Challenge())
+		}
+	}
+
+	if len(challenges) > 0 {
+		ctx.HttpResponse().SetHeader("WWW-Authenticate", strings.Join(challenges, ", "))
+	}
+}
+
+// authorize checks the requirements declared by a [Secured] Action.
+func authorize(ctx GmvcContext, handler interface{}) error {
+	secured, ok := handler.(Secured)
+	if !ok {
+		return nil
+	}
+
+	principal := GetPrincipal(ctx)
+	if principal == nil {
+		return unauthorized(ErrUnauthorized)
+	}
+
+	if roles := secured.RequiredRoles(); len(roles) > 0 {
+		granted := false
+		for _, role := range roles {
+			if principal.HasRole(role) {
+				granted = true
+				break
+			}
+		}
+
+		if !granted {
+			return forbidden(fmt.Errorf("%w: requires one of roles %v", ErrForbidden, roles))
+		}
+	}
+
+	for _, scope := range secured.RequiredScopes() {
+		if !principal.HasScope(scope) {
+			return forbidden(fmt.Errorf("%w: requires scope %s", ErrForbidden, scope))
+		}
+	}
+
+	return nil
+}
+
+var _ Authenticator = (*BasicAuthenticator)(nil)
+var _ Challenger = (*BasicAuthenticator)(nil)
+
+// BasicAuthenticator authenticates `Authorization: Basic` credentials.
+type BasicAuthenticator struct {
+	// Realm is sent back in the `WWW-Authenticate` challenge.
+	Realm string
+
+	// Validate checks the username and password.
+	// It returns nil principal if the credentials are wrong.
+	Validate func(ctx GmvcContext, username, password string) (*Principal, error)
+}
+
+// Authenticate implements Authenticator.
+func (a *BasicAuthenticator) Authenticate(ctx GmvcContext) (*Principal, error) {
+	credentials, ok := authorization(ctx, "Basic")
+	if !ok {
+		return nil, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed basic credentials", ErrUnauthorized)
+	}
+
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed basic credentials", ErrUnauthorized)
+	}
+
+	principal, err := a.Validate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal == nil {
+		return nil, fmt.Errorf("%w: invalid username or password", ErrUnauthorized)
+	}
+
+	if principal.Scheme == "" {
+		principal.Scheme = "Basic"
+	}
+
+	return principal, nil
+}
+
+// Challenge implements Challenger.
+func (a *BasicAuthenticator) Challenge() string {
+	return fmt.Sprintf("Basic realm=%q", a.Realm)
+}
+
+var _ Authenticator = (*APIKeyAuthenticator)(nil)
+
+// APIKeyAuthenticator authenticates requests carrying an api key in a header or in the query.
+type APIKeyAuthenticator struct {
+	// Header is the header carrying the api key, "X-Api-Key" if both Header and Query are empty.
+	Header string
+
+	// Query is the query parameter carrying the api key.
+	Query string
+
+	// Lookup finds the principal owning the key.
+	// It returns nil principal if the key is unknown.
+	Lookup func(ctx GmvcContext, key string) (*Principal, error)
+}
+
+// Authenticate implements Authenticator.
+func (a *APIKeyAuthenticator) Authenticate(ctx GmvcContext) (*Principal, error) {
+	header := a.Header
+	if header == "" && a.Query == "" {
+		header = "X-Api-Key"
+	}
+
+	var key string
+	var ok bool
+	if header != "" {
+		key, ok = ctx.HttpRequest().Header().Get(header)
+	}
+
+	if !ok && a.Query != "" {
+		key, ok = ctx.HttpRequest().GetQuery(a.Query)
+	}
+
+	if !ok || key == "" {
+		return nil, nil
+	}
+
+	principal, err := a.Lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal == nil {
+		return nil, fmt.Errorf("%w: invalid api key", ErrUnauthorized)
+	}
+
+	if principal.Scheme == "" {
+		principal.Scheme = "ApiKey"
+	}
+
+	return principal, nil
+}
+
+// authorization returns the credentials of the `Authorization` header with the given scheme.
+func authorization(ctx GmvcContext, scheme string) (string, bool) {
+	value, ok := ctx.HttpRequest().Header().Get("Authorization")
+	if !ok {
+		return "", false
+	}
+
+	prefix, credentials, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok || !strings.EqualFold(prefix, scheme) {
+		return "", false
+	}
+
+	return strings.TrimSpace(credentials), true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
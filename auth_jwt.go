@@ -0,0 +1,252 @@
+package gmvc
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA256
+	_ "crypto/sha512" // registers SHA384 & SHA512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken means the bearer token is malformed or its signature does not match.
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrTokenExpired means the bearer token is expired or not valid yet.
+	ErrTokenExpired = errors.New("token expired")
+)
+
+var _ Authenticator = (*JWTAuthenticator)(nil)
+var _ Challenger = (*JWTAuthenticator)(nil)
+
+// JWTAuthenticator authenticates `Authorization: Bearer` JSON Web Tokens.
+// HS256/HS384/HS512 tokens are verified by Secret, RS256/RS384/RS512 tokens are verified by PublicKey.
+// Tokens signed by any other algorithm, including "none", are rejected.
+type JWTAuthenticator struct {
+	// Secret verifies HMAC signed tokens.
+	Secret []byte
+
+	// PublicKey verifies RSA signed tokens.
+	PublicKey *rsa.PublicKey
+
+	// KeyFunc, if set, chooses the verifying key by the token header (eg. by "kid"), overrides Secret and PublicKey.
+	// It must return a []byte for HMAC tokens or a *rsa.PublicKey for RSA tokens.
+	KeyFunc func(header map[string]any) (any, error)
+
+	// Issuer, if set, must equal to the "iss" claim.
+	Issuer string
+
+	// Audience, if set, must be contained in the "aud" claim.
+	Audience string
+
+	// Leeway tolerates the clock skew when checking "exp" and "nbf".
+	Leeway time.Duration
+
+	// RolesClaim is the claim holding the roles, "roles" by default.
+	RolesClaim string
+
+	// ScopesClaim is the claim holding the scopes, "scope" by default.
+	// Both space-delimited string and string array are accepted.
+	ScopesClaim string
+
+	now func() time.Time
+}
+
+// Challenge implements Challenger.
+func (a *JWTAuthenticator) Challenge() string {
+	return "Bearer"
+}
+
+// Authenticate implements Authenticator.
+func (a *JWTAuthenticator) Authenticate(ctx GmvcContext) (*Principal, error) {
+	token, ok := authorization(ctx, "Bearer")
+	if !ok {
+		return nil, nil
+	}
+
+	claims, err := a.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := &Principal{
+		Scheme: "Bearer",
+		Claims: claims,
+	}
+
+	principal.Subject, _ = claims["sub"].(string)
+
+	rolesClaim := a.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+	principal.Roles = claimStrings(claims[rolesClaim])
+
+	scopesClaim := a.ScopesClaim
+	if scopesClaim == "" {
+		scopesClaim = "scope"
+	}
+	principal.Scopes = claimStrings(claims[scopesClaim])
+
+	return principal, nil
+}
+
+// Verify checks the signature and the registered claims of the token, and returns its claims.
+func (a *JWTAuthenticator) Verify(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	header := make(map[string]any)
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	var key any
+	if a.KeyFunc != nil {
+		if key, err = a.KeyFunc(header); err != nil {
+			return nil, err
+		}
+	}
+
+	alg, _ := header["alg"].(string)
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := a.verifySignature(alg, key, signed, signature); err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]any)
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) verifySignature(alg string, key any, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "HS256", "RS256":
+		hash = crypto.SHA256
+	case "HS384", "RS384":
+		hash = crypto.SHA384
+	case "HS512", "RS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, alg)
+	}
+
+	switch alg[:2] {
+	case "HS":
+		secret := a.Secret
+		if key != nil {
+			secret, _ = key.([]byte)
+		}
+
+		if len(secret) == 0 {
+			return fmt.Errorf("%w: no secret for %s", ErrInvalidToken, alg)
+		}
+
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	case "RS":
+		publicKey := a.PublicKey
+		if key != nil {
+			publicKey, _ = key.(*rsa.PublicKey)
+		}
+
+		if publicKey == nil {
+			return fmt.Errorf("%w: no public key for %s", ErrInvalidToken, alg)
+		}
+
+		h := hash.New()
+		h.Write(signed)
+		if err := rsa.VerifyPKCS1v15(publicKey, hash, h.Sum(nil), signature); err != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	}
+
+	return nil
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) error {
+	now := time.Now()
+	if a.now != nil {
+		now = a.now()
+	}
+
+	if exp, ok := claims["exp"].(float64); ok {
+		if now.After(time.Unix(int64(exp), 0).Add(a.Leeway)) {
+			return ErrTokenExpired
+		}
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if now.Add(a.Leeway).Before(time.Unix(int64(nbf), 0)) {
+			return fmt.Errorf("%w: token not valid yet", ErrTokenExpired)
+		}
+	}
+
+	if a.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.Issuer {
+			return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+		}
+	}
+
+	if a.Audience != "" && !contains(claimStrings(claims["aud"]), a.Audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// claimStrings reads a claim which is either a space-delimited string or an array of strings.
+func claimStrings(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		ret := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				ret = append(ret, s)
+			}
+		}
+
+		return ret
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package gmvc
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signJWT(t *testing.T, alg string, key any, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]any{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		assert.Nil(t, err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTAuthenticatorHS256(t *testing.T) {
+	secret := []byte("secret")
+	auth := &JWTAuthenticator{Secret: secret, Issuer: "gmvc"}
+
+	token := signJWT(t, "HS256", secret, map[string]any{
+		"sub":   "u1",
+		"iss":   "gmvc",
+		"roles": []string{"admin"},
+		"scope": "read write",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+
+	claims, err := auth.Verify(token)
+	assert.Nil(t, err)
+	assert.Equal(t, "u1", claims["sub"])
+
+	_, err = auth.Verify(signJWT(t, "HS256", []byte("other"), map[string]any{"sub": "u1", "iss": "gmvc"}))
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+
+	_, err = auth.Verify(signJWT(t, "HS256", secret, map[string]any{"iss": "gmvc", "exp": time.Now().Add(-time.Hour).Unix()}))
+	assert.True(t, errors.Is(err, ErrTokenExpired))
+
+	_, err = auth.Verify(signJWT(t, "none", nil, map[string]any{"iss": "gmvc"}))
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestJWTAuthenticatorRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	auth := &JWTAuthenticator{PublicKey: &key.PublicKey}
+	_, err = auth.Verify(signJWT(t, "RS256", key, map[string]any{"sub": "u1"}))
+	assert.Nil(t, err)
+
+	// a HMAC token must not be verified with the RSA public key
+	_, err = auth.Verify(signJWT(t, "HS256", []byte("secret"), map[string]any{"sub": "u1"}))
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+type securedAction struct {
+	Principal *Principal `param:"Principal"`
+}
+
+func (a *securedAction) RequiredRoles() []string  { return []string{"admin"} }
+func (a *securedAction) RequiredScopes() []string { return []string{"read"} }
+
+func (a *securedAction) Go() (interface{}, error) {
+	return a.Principal.Subject, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	secret := []byte("secret")
+	builder := CreateGmvcBuilder()
+	middleware := NewAuthMiddleware(&JWTAuthenticator{Secret: secret})
+
+	// anonymous
+	ctx := newMockContext(http.MethodGet, "/secured")
+	serve(builder, &securedAction{}, ctx, middleware)
+	assert.Equal(t, http.StatusUnauthorized, ctx.resp.status)
+	challenge, _ := ctx.resp.header.Get("WWW-Authenticate")
+	assert.Equal(t, "Bearer", challenge)
+
+	// missing role
+	ctx = newMockContext(http.MethodGet, "/secured")
+	ctx.req.header.set("Authorization", "Bearer "+signJWT(t, "HS256", secret, map[string]any{"sub": "u1", "scope": "read"}))
+	serve(builder, &securedAction{}, ctx, middleware)
+	assert.Equal(t, http.StatusForbidden, ctx.resp.status)
+
+	// granted
+	ctx = newMockContext(http.MethodGet, "/secured")
+	ctx.req.header.set("Authorization", "Bearer "+signJWT(t, "HS256", secret, map[string]any{"sub": "u1", "roles": []string{"admin"}, "scope": "read"}))
+	serve(builder, &securedAction{}, ctx, middleware)
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, `"u1"`, bodyString(ctx))
+}
+
+func TestBasicAuthenticator(t *testing.T) {
+	auth := &BasicAuthenticator{
+		Realm: "gmvc",
+		Validate: func(ctx GmvcContext, username, password string) (*Principal, error) {
+			if username == "gmvc" && password == "pass" {
+				return &Principal{Subject: username}, nil
+			}
+
+			return nil, nil
+		},
+	}
+
+	ctx := newMockContext(http.MethodGet, "/")
+	ctx.req.header.set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("gmvc:pass")))
+	principal, err := auth.Authenticate(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "Basic", principal.Scheme)
+
+	ctx.req.header.set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("gmvc:wrong")))
+	_, err = auth.Authenticate(ctx)
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+}
+
+func TestAuthMiddlewareBackendError(t *testing.T) {
+	lookup := func(ctx GmvcContext, key string) (*Principal, error) {
+		if key == "down" {
+			return nil, errors.New("user store is down")
+		}
+
+		return nil, nil
+	}
+	middleware := NewAuthMiddleware(&APIKeyAuthenticator{Lookup: lookup})
+
+	// failures other than invalid credentials are not answered with 401
+	ctx := newMockContext(http.MethodGet, "/secured")
+	ctx.req.header.set("X-Api-Key", "down")
+	serve(CreateGmvcBuilder(), &securedAction{}, ctx, middleware)
+	assert.Equal(t, http.StatusInternalServerError, ctx.resp.status)
+
+	ctx = newMockContext(http.MethodGet, "/secured")
+	ctx.req.header.set("X-Api-Key", "unknown")
+	serve(CreateGmvcBuilder(), &securedAction{}, ctx, middleware)
+	assert.Equal(t, http.StatusUnauthorized, ctx.resp.status)
+}
@@ -0,0 +1,477 @@
+package gmvc
+
+import (
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type multiValueAction struct {
+	IDs    []int    `param:"id,Query" json:"ids"`
+	Tags   []string `param:"tag,Query,sep=|" json:"tags"`
+	Names  []string `param:"name,Query,sep=none" json:"names"`
+	Colors []string `param:"color,Form" json:"colors"`
+	Traces []string `param:"X-Trace,Header" json:"traces"`
+	Single string   `param:"id,Query" json:"single"`
+}
+
+func (a *multiValueAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestBindMultiValue(t *testing.T) {
+	ctx := newMockContext(http.MethodPost, "/?id=1&id=2,3&tag=a,b|c&tag=d&name=x,y&name=z")
+	ctx.req.form.Add("color", "red")
+	ctx.req.form.Add("color", "green")
+	http.Header(ctx.req.header).Add("X-Trace", "t1")
+	http.Header(ctx.req.header).Add("X-Trace", "t2")
+	serve(CreateGmvcBuilder(), &multiValueAction{}, ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.JSONEq(t, `{
+		"ids": [1, 2, 3],
+		"tags": ["a,b", "c", "d"],
+		"names": ["x,y", "z"],
+		"colors": ["red", "green"],
+		"traces": ["t1", "t2"],
+		"single": "1"
+	}`, bodyString(ctx))
+}
+
+func TestBindMultiValueInvalid(t *testing.T) {
+	ctx := newMockContext(http.MethodGet, "/?id=1&id=x")
+	serve(CreateGmvcBuilder(), &multiValueAction{}, ctx)
+
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "id")
+}
+
+type nestedFilter struct {
+	Status string   `param:"status"`
+	Tags   []string `param:"tags"`
+	Range  *struct {
+		Min int `param:"min"`
+		Max int `param:"max"`
+	} `param:"range"`
+}
+
+type nestedItem struct {
+	Name  string `param:"name"`
+	Count int    `param:"count"`
+}
+
+type nestedAction struct {
+	Filter nestedFilter      `param:"filter,Query" json:"filter"`
+	Items  []nestedItem      `param:"items,Query,Form" json:"items"`
+	Labels map[string]string `param:"labels,Query" json:"labels"`
+}
+
+func (a *nestedAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestBindNested(t *testing.T) {
+	target := "/?filter[status]=open&filter[tags][]=a&filter[tags][]=b&filter.range.min=1&filter[range][max]=9" +
+		"&items[1].name=y&items[0].name=x&items[0][count]=2&labels[env]=prod&labels.team=core"
+	ctx := newMockContext(http.MethodGet, target)
+	serve(CreateGmvcBuilder(), &nestedAction{}, ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.JSONEq(t, `{
+		"filter": {"Status": "open", "Tags": ["a", "b"], "Range": {"Min": 1, "Max": 9}},
+		"items": [{"Name": "x", "Count": 2}, {"Name": "y", "Count": 0}],
+		"labels": {"env": "prod", "team": "core"}
+	}`, bodyString(ctx))
+}
+
+func TestBindNestedForm(t *testing.T) {
+	ctx := newMockContext(http.MethodPost, "/")
+	ctx.req.form.Add("items[0][name]", "x")
+	serve(CreateGmvcBuilder(), &nestedAction{}, ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), `"items":[{"Name":"x","Count":0}]`)
+}
+
+func TestBindNestedLimit(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+	}{
+		{name: "too deep", target: "/?filter[range][min][a][b][c]=1"},
+		{name: "too many elements", target: "/?items[0].name=a&items[1].name=b&items[2].name=c"},
+		{name: "malformed key", target: "/?filter[status=open"},
+		{name: "invalid index", target: "/?items[x].name=a"},
+		{name: "invalid value", target: "/?items[0].count=x"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := newMockContext(http.MethodGet, c.target)
+			serve(CreateGmvcBuilder(NestedLimit(4, 2)), &nestedAction{}, ctx)
+			assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+		})
+	}
+}
+
+type timeAction struct {
+	Since   time.Time     `param:"since,Query"`
+	Day     *time.Time    `param:"day,Query" layout:"2006-01-02"`
+	Stamps  []time.Time   `param:"ts,Query" layout:"unix"`
+	Timeout time.Duration `param:"timeout,Query" default:"5s"`
+	IP      net.IP        `param:"ip,Query"`
+	Window  struct {
+		From time.Time `param:"from" layout:"2006-01-02"`
+	} `param:"window,Query"`
+}
+
+func (a *timeAction) Go() (interface{}, error) {
+	return map[string]interface{}{
+		"since":   a.Since.UTC().Format(time.RFC3339),
+		"day":     a.Day.Format("2006-01-02"),
+		"stamps":  []int64{a.Stamps[0].Unix(), a.Stamps[1].Unix()},
+		"timeout": a.Timeout.String(),
+		"ip":      a.IP.String(),
+		"from":    a.Window.From.Format("2006-01-02"),
+	}, nil
+}
+
+func TestBindTime(t *testing.T) {
+	ctx := newMockContext(http.MethodGet, "/?since=2024-01-02T03:04:05Z&day=2024-02-03&ts=1&ts=2&ip=10.0.0.1&window[from]=2024-03-04")
+	serve(CreateGmvcBuilder(), &timeAction{}, ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.JSONEq(t, `{
+		"since": "2024-01-02T03:04:05Z",
+		"day": "2024-02-03",
+		"stamps": [1, 2],
+		"timeout": "5s",
+		"ip": "10.0.0.1",
+		"from": "2024-03-04"
+	}`, bodyString(ctx))
+
+	ctx = newMockContext(http.MethodGet, "/?since=yesterday")
+	serve(CreateGmvcBuilder(), &timeAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+}
+
+type testStatus string
+
+type testUser struct {
+	ID int
+}
+
+type prefixAction struct {
+	Meta   map[string]string   `param:"Header,prefix=X-Meta-" json:"meta"`
+	Attrs  map[string][]int    `param:"Query,prefix=attr_" json:"attrs"`
+	Status testStatus          `param:"status,Query" json:"status"`
+	User   *testUser           `param:"user,Query" json:"user"`
+	Empty  map[string][]string `param:"Query,prefix=none_" json:"empty"`
+}
+
+func (a *prefixAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestBindPrefix(t *testing.T) {
+	RegisterConverter(func(s string) (*testUser, error) {
+		id, err := Convert(s, reflect.TypeOf(0))
+		if err != nil {
+			return nil, err
+		}
+
+		return &testUser{ID: id.(int)}, nil
+	})
+
+	ctx := newMockContext(http.MethodGet, "/?attr_size=1,2&attr_size=3&attr_age=4&status=open&user=7")
+	ctx.req.header.set("X-Meta-Trace", "t1")
+	ctx.req.header.set("X-Meta-Zone", "cn")
+	ctx.req.header.set("X-Other", "o")
+	serve(CreateGmvcBuilder(), &prefixAction{}, ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.JSONEq(t, `{
+		"meta": {"Trace": "t1", "Zone": "cn"},
+		"attrs": {"size": [1, 2, 3], "age": [4]},
+		"status": "open",
+		"user": {"ID": 7},
+		"empty": null
+	}`, bodyString(ctx))
+}
+
+type chanAction struct {
+	C chan int `param:"Query"`
+}
+
+func (a *chanAction) Go() (interface{}, error) { return nil, nil }
+
+type headerMapAction struct {
+	M map[string]string `param:"Header"`
+}
+
+func (a *headerMapAction) Go() (interface{}, error) { return nil, nil }
+
+type prefixSliceAction struct {
+	M []string `param:"Header,prefix=X-"`
+}
+
+func (a *prefixSliceAction) Go() (interface{}, error) { return nil, nil }
+
+type prefixResolverAction struct {
+	M map[string]string `param:"Query,prefix=m_" resolver:"upper"`
+}
+
+func (a *prefixResolverAction) Go() (interface{}, error) { return nil, nil }
+
+type prefixTypedResolverAction struct {
+	M map[string]string `param:"Query,prefix=m_"`
+}
+
+func (a *prefixTypedResolverAction) Go() (interface{}, error) { return nil, nil }
+
+type testCode struct {
+	Code string
+}
+
+type registeredPtrAction struct {
+	U *testCode `param:"Header"`
+	C chan int  `param:"Body"`
+}
+
+func (a *registeredPtrAction) Go() (interface{}, error) { return nil, nil }
+
+func TestBuildUnsupportedType(t *testing.T) {
+	assert.PanicsWithValue(t, "gmvc: unsupported type chan int of field chanAction.C, register a converter, a typed resolver or a resolver for it", func() {
+		CreateGmvcBuilder().BuildAction(&chanAction{})
+	})
+
+	assert.Panics(t, func() { CreateGmvcBuilder().BuildAction(&headerMapAction{}) })
+	assert.Panics(t, func() { CreateGmvcBuilder().BuildAction(&prefixSliceAction{}) })
+
+	// resolvers get a single string, which prefixed parameters are not
+	upper := func(ctx GmvcContext, fieldMeta *ParamMeta, value string) (interface{}, error) { return value, nil }
+	assert.PanicsWithValue(t, "gmvc: field prefixResolverAction.M with prefix can't have a resolver", func() {
+		CreateGmvcBuilder().RegisterResolver("upper", upper).BuildAction(&prefixResolverAction{})
+	})
+	assert.Panics(t, func() {
+		CreateGmvcBuilder().RegisterTypedResolver([]reflect.Type{reflect.TypeOf(map[string]string{})}, upper).BuildAction(&prefixTypedResolverAction{})
+	})
+
+	assert.Panics(t, func() { CreateGmvcBuilder().BuildAction(&registeredPtrAction{}) })
+
+	// converter of testCode also converts *testCode, and Body fields are decoded as a whole
+	RegisterConverter(func(s string) (testCode, error) { return testCode{Code: s}, nil })
+	assert.NotPanics(t, func() { CreateGmvcBuilder().BuildAction(&registeredPtrAction{}) })
+}
+
+type aliasAction struct {
+	UserID    int64  `param:"Query,name=user_id|userId|UserID" json:"user_id"`
+	RealIP    string `param:"Header,x-real-ip" json:"real_ip"`
+	PageSize  int    `param:"Query" json:"page_size"`
+	HTTPProxy string `param:"Header" json:"http_proxy"`
+}
+
+func (a *aliasAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestBindAlias(t *testing.T) {
+	for _, target := range []string{"/?user_id=7", "/?userId=7", "/?UserID=7", "/?userId=7&user_id=7"} {
+		ctx := newMockContext(http.MethodGet, target)
+		serve(CreateGmvcBuilder(), &aliasAction{}, ctx)
+		assert.Contains(t, bodyString(ctx), `"user_id":7`, target)
+	}
+
+	ctx := newMockContext(http.MethodGet, "/")
+	ctx.req.header.set("X-Real-Ip", "10.0.0.1")
+	serve(CreateGmvcBuilder(), &aliasAction{}, ctx)
+	assert.Contains(t, bodyString(ctx), `"real_ip":"10.0.0.1"`)
+}
+
+func TestBindNaming(t *testing.T) {
+	cases := []struct {
+		naming NamingStrategy
+		target string
+		header string
+	}{
+		{naming: SnakeCase, target: "/?page_size=20"},
+		{naming: KebabCase, target: "/?page-size=20"},
+		{naming: CamelCase, target: "/?pageSize=20"},
+	}
+
+	for _, c := range cases {
+		// the naming strategy doesn't apply to headers
+		ctx := newMockContext(http.MethodGet, c.target)
+		ctx.req.header.set("HTTPProxy", "proxy")
+		serve(CreateGmvcBuilder(Naming(c.naming)), &aliasAction{}, ctx)
+		assert.JSONEq(t, `{"user_id":0,"real_ip":"","page_size":20,"http_proxy":"proxy"}`, bodyString(ctx), c.target)
+	}
+}
+
+type namingCtxAction struct {
+	TenantID string `param:"Ctx" json:"tenant_id"`
+}
+
+func (a *namingCtxAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestBindNamingCtx(t *testing.T) {
+	// keys set by middlewares are not renamed
+	ctx := newMockContext(http.MethodGet, "/")
+	ctx.Set("TenantID", "t1")
+	serve(CreateGmvcBuilder(Naming(SnakeCase)), &namingCtxAction{}, ctx)
+	assert.JSONEq(t, `{"tenant_id":"t1"}`, bodyString(ctx))
+}
+
+type foldCaseAction struct {
+	UserID int64             `param:"user_id,Query" json:"user_id"`
+	Name   string            `param:"name,Form" json:"name"`
+	ID     string            `param:"id,Path" json:"id"`
+	Token  string            `param:"token,Cookie" json:"token"`
+	Filter map[string]string `param:"filter,Query" json:"filter"`
+}
+
+func (a *foldCaseAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestBindFoldCase(t *testing.T) {
+	newCtx := func() *mockContext {
+		ctx := newMockContext(http.MethodPost, "/?User_ID=7&Filter[status]=open")
+		ctx.req.form.Add("NAME", "gmvc")
+		ctx.req.path["ID"] = "42"
+		ctx.req.header.set("Cookie", "Token=abc")
+		return ctx
+	}
+
+	ctx := newCtx()
+	serve(CreateGmvcBuilder(FoldCase()), &foldCaseAction{}, ctx)
+	assert.JSONEq(t, `{"user_id":7,"name":"gmvc","id":"42","token":"abc","filter":{"status":"open"}}`, bodyString(ctx))
+
+	// names match exactly by default
+	ctx = newCtx()
+	serve(CreateGmvcBuilder(), &foldCaseAction{}, ctx)
+	assert.JSONEq(t, `{"user_id":0,"name":"","id":"","token":"","filter":null}`, bodyString(ctx))
+}
+
+func TestSplitWords(t *testing.T) {
+	assert.Equal(t, []string{"HTTP", "Server", "ID"}, splitWords("HTTPServerID"))
+	assert.Equal(t, []string{"user", "Id"}, splitWords("userId"))
+	assert.Equal(t, []string{"Page", "Size2"}, splitWords("PageSize2"))
+	assert.Equal(t, []string{"user", "id"}, splitWords("user_id"))
+	assert.Equal(t, "user_id", SnakeCase("UserID"))
+	assert.Equal(t, "user-id", KebabCase("UserID"))
+	assert.Equal(t, "userId", CamelCase("UserID"))
+}
+
+type tagNameAddress struct {
+	City    string `json:"city_name,omitempty"`
+	Country string `json:"-"`
+}
+
+type tagNameAction struct {
+	UserID  int64          `param:"Query" json:"user_id,omitempty"`
+	Keyword string         `param:"Query" form:"q" json:"keyword"`
+	Secret  string         `param:"Query" json:"-"`
+	Dash    string         `param:"Query" json:"-,"`
+	Renamed string         `param:"Query,name" json:"renamed"`
+	Address tagNameAddress `param:"Query" json:"address"`
+}
+
+func (a *tagNameAction) Go() (interface{}, error) {
+	return map[string]interface{}{
+		"user_id": a.UserID,
+		"keyword": a.Keyword,
+		"secret":  a.Secret,
+		"dash":    a.Dash,
+		"renamed": a.Renamed,
+		"city":    a.Address.City,
+		"country": a.Address.Country,
+	}, nil
+}
+
+func TestBindTagNames(t *testing.T) {
+	target := "/?user_id=7&q=gmvc&Secret=s&-=d&name=n&address[city_name]=sz&address[Country]=cn"
+	ctx := newMockContext(http.MethodGet, target)
+	serve(CreateGmvcBuilder(TagNames("form", "json")), &tagNameAction{}, ctx)
+
+	assert.JSONEq(t, `{
+		"user_id": 7,
+		"keyword": "gmvc",
+		"secret": "",
+		"dash": "d",
+		"renamed": "n",
+		"city": "sz",
+		"country": ""
+	}`, bodyString(ctx))
+}
+
+type tagNameBodyAction struct {
+	Tenant  string          `param:"Ctx" json:"-"`
+	Address *tagNameAddress `param:"Body" json:"-"`
+}
+
+func (a *tagNameBodyAction) Go() (interface{}, error) {
+	return map[string]interface{}{"tenant": a.Tenant, "address": a.Address}, nil
+}
+
+func TestBindTagNamesIgnoredBody(t *testing.T) {
+	// "-" only ignores the sources looked up by name
+	ctx := newMockContext(http.MethodPost, "/")
+	ctx.Set("Tenant", "t1")
+	ctx.req.header.set("Content-Type", "application/json")
+	ctx.req.body = []byte(`{"city_name":"sz"}`)
+	serve(CreateGmvcBuilder(TagNames("json")), &tagNameBodyAction{}, ctx)
+	assert.JSONEq(t, `{"tenant":"t1","address":{"city_name":"sz"}}`, bodyString(ctx))
+}
+
+type autoOrderAction struct {
+	ID   string `param:"id,Auto" json:"id"`
+	Name string `param:"name,Auto(Path,Query)" json:"name"`
+}
+
+func (a *autoOrderAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func serveAutoOrder(target string, options ...GmvcOption) *mockContext {
+	ctx := newMockContext(http.MethodGet, target)
+	ctx.req.path["id"] = "path-id"
+	ctx.req.path["name"] = "path-name"
+	ctx.req.header.set("id", "header-id")
+	serve(CreateGmvcBuilder(options...), &autoOrderAction{}, ctx)
+	return ctx
+}
+
+func TestAutoOrder(t *testing.T) {
+	// Header first by default, Path before Query for the field
+	ctx := serveAutoOrder("/?id=query-id&name=query-name")
+	assert.JSONEq(t, `{"id":"header-id","name":"path-name"}`, bodyString(ctx))
+
+	ctx = serveAutoOrder("/?id=query-id&name=query-name", DefineAuto(PathSrc, QuerySrc))
+	assert.JSONEq(t, `{"id":"path-id","name":"path-name"}`, bodyString(ctx))
+
+	ctx = serveAutoOrder("/?id=query-id", DefineAuto(QuerySrc, PathSrc))
+	assert.JSONEq(t, `{"id":"query-id","name":"path-name"}`, bodyString(ctx))
+}
+
+func TestRejectAmbiguous(t *testing.T) {
+	ctx := serveAutoOrder("/?id=path-id&name=path-name", DefineAuto(PathSrc, QuerySrc), RejectAmbiguous())
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+
+	ctx = serveAutoOrder("/?name=query-name", DefineAuto(PathSrc, QuerySrc), RejectAmbiguous())
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "name")
+}
+
+func TestSplitParam(t *testing.T) {
+	assert.Equal(t, []string{"id", "Auto(Path,Query)", "sep=|"}, splitParam("id,Auto(Path,Query),sep=|"))
+	assert.Equal(t, []Src{PathSrc, QuerySrc, HeaderSrc}, sourceOrder(HeaderSrc|QuerySrc|PathSrc, []Src{PathSrc, QuerySrc}))
+	assert.Equal(t, "Query|Path", (QuerySrc | PathSrc).String())
+	assert.Panics(t, func() { parseAuto("Auto(Path,Nowhere)") })
+}
@@ -1,13 +1,251 @@
 package gmvc
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
 
 var (
+	// RequiredChecker requires the parameter to be present in the request, default values are not counted.
+	// Empty values, eg. "?name=", are refused unless the field is also "nullable".
 	RequiredChecker = func(ctx GmvcContext, fieldMeta *ParamMeta, value interface{}) error {
-		if value == nil {
+		if !ctx.Present(fieldMeta.path) {
 			return fmt.Errorf("field %s is required", fieldMeta.GetName())
 		}
 
+		if !fieldMeta.nullable && isEmptyValue(reflect.ValueOf(value)) {
+			return fmt.Errorf("field %s must not be empty", fieldMeta.GetName())
+		}
+
+		return nil
+	}
+
+	// NotEmptyChecker refuses empty values, that is empty strings, slices and maps, nil pointers, and unset [Optional].
+	// Numbers and booleans are never empty.
+	NotEmptyChecker = func(ctx GmvcContext, fieldMeta *ParamMeta, value interface{}) error {
+		if isEmptyValue(reflect.ValueOf(value)) {
+			return fmt.Errorf("field %s must not be empty", fieldMeta.GetName())
+		}
+
 		return nil
 	}
 )
+
+func isEmptyValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+
+	if opt, ok := asOptional(v); ok {
+		elem, set := opt.get()
+		return !set || isEmptyValue(elem)
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// crossChecker checks the value of the field against the other fields of the same struct, eg. gtfield(StartTime).
+type crossChecker func(ctx GmvcContext, fieldMeta *ParamMeta, value, parent reflect.Value) error
+
+// crossCheckers build the crossCheckers from the arguments in the tag, errors are reported at BuildAction.
+var crossCheckers = map[string]func(typ reflect.Type, field reflect.StructField, args []string) (crossChecker, error){
+	"eqfield":     compareField("equal to", func(c int) bool { return c == 0 }, false),
+	"nefield":     compareField("different from", func(c int) bool { return c != 0 }, false),
+	"gtfield":     compareField("greater than", func(c int) bool { return c > 0 }, true),
+	"gtefield":    compareField("greater than or equal to", func(c int) bool { return c >= 0 }, true),
+	"ltfield":     compareField("less than", func(c int) bool { return c < 0 }, true),
+	"ltefield":    compareField("less than or equal to", func(c int) bool { return c <= 0 }, true),
+	"required_if": requiredIf,
+}
+
+// compareField compares the field with the other field, values which are absent, that is nil pointers and unset Optional,
+// are left to required.
+func compareField(relation string, accept func(c int) bool, ordered bool) func(reflect.Type, reflect.StructField, []string) (crossChecker, error) {
+	return func(typ reflect.Type, field reflect.StructField, args []string) (crossChecker, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("1 argument is expected, got %d", len(args))
+		}
+
+		other, ok := typ.FieldByName(args[0])
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", args[0])
+		}
+
+		if t1, t2 := unwrapType(field.Type), unwrapType(other.Type); t1 != t2 {
+			return nil, fmt.Errorf("type %s can't be compared with %s of field %s", t1, t2, args[0])
+		} else if ordered && !isOrdered(t1) {
+			return nil, fmt.Errorf("type %s is not ordered", t1)
+		}
+
+		return func(ctx GmvcContext, fieldMeta *ParamMeta, value, parent reflect.Value) error {
+			v1, ok1 := unwrapValue(value)
+			v2, ok2 := unwrapValue(parent.FieldByIndex(other.Index))
+			if !ok1 || !ok2 {
+				return nil
+			}
+
+			if !accept(compareValues(v1, v2)) {
+				return fmt.Errorf("field %s must be %s %s", fieldMeta.GetName(), relation, args[0])
+			}
+
+			return nil
+		}, nil
+	}
+}
+
+// requiredIf requires the field if the other field equals any of the values, eg. required_if(Type,card,wallet).
+func requiredIf(typ reflect.Type, field reflect.StructField, args []string) (crossChecker, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("at least 2 arguments are expected, got %d", len(args))
+	}
+
+	other, ok := typ.FieldByName(args[0])
+	if !ok {
+		return nil, fmt.Errorf("unknown field %s", args[0])
+	}
+
+	return func(ctx GmvcContext, fieldMeta *ParamMeta, value, parent reflect.Value) error {
+		v, ok := unwrapValue(parent.FieldByIndex(other.Index))
+		if !ok {
+			return nil
+		}
+
+		for _, expected := range args[1:] {
+			if fmt.Sprint(v.Interface()) == expected {
+				return RequiredChecker(ctx, fieldMeta, value.Interface())
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+// parseChecker parses the checker with arguments, eg. "required_if(Type,card)" into "required_if" and ["Type", "card"].
+func parseChecker(s string) (name string, args []string, ok bool) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return s, nil, false
+	}
+
+	for _, arg := range strings.Split(s[open+1:len(s)-1], XSplit) {
+		args = append(args, strings.TrimSpace(arg))
+	}
+
+	return s[:open], args, true
+}
+
+// asOptional returns the optional of the value, if it's Optional.
+func asOptional(v reflect.Value) (optional, bool) {
+	if !reflect.PointerTo(v.Type()).Implements(optionalType) {
+		return nil, false
+	}
+
+	if v.CanAddr() {
+		return v.Addr().Interface().(optional), true
+	}
+
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr.Interface().(optional), true
+}
+
+// unwrapValue dereferences pointers and Optional, false if the value is absent.
+func unwrapValue(v reflect.Value) (reflect.Value, bool) {
+	for {
+		if opt, ok := asOptional(v); ok {
+			elem, set := opt.get()
+			if !set {
+				return reflect.Value{}, false
+			}
+
+			v = elem
+			continue
+		}
+
+		if v.Kind() != reflect.Pointer {
+			return v, true
+		}
+
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		v = v.Elem()
+	}
+}
+
+// unwrapType dereferences pointers and Optional.
+func unwrapType(typ reflect.Type) reflect.Type {
+	for {
+		if reflect.PointerTo(typ).Implements(optionalType) {
+			typ = reflect.New(typ).Interface().(optional).elemType()
+			continue
+		}
+
+		if typ.Kind() != reflect.Pointer {
+			return typ
+		}
+
+		typ = typ.Elem()
+	}
+}
+
+func isOrdered(typ reflect.Type) bool {
+	if typ == timeType {
+		return true
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+
+	return false
+}
+
+// compareValues returns -1, 0 or 1 for ordered values, and 0 or 1 for equal or different values of other types.
+func compareValues(v1, v2 reflect.Value) int {
+	sign := func(less, greater bool) int {
+		switch {
+		case less:
+			return -1
+		case greater:
+			return 1
+		}
+
+		return 0
+	}
+
+	if v1.Type() == timeType {
+		t1, t2 := v1.Interface().(time.Time), v2.Interface().(time.Time)
+		return sign(t1.Before(t2), t1.After(t2))
+	}
+
+	switch v1.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return sign(v1.Int() < v2.Int(), v1.Int() > v2.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return sign(v1.Uint() < v2.Uint(), v1.Uint() > v2.Uint())
+	case reflect.Float32, reflect.Float64:
+		return sign(v1.Float() < v2.Float(), v1.Float() > v2.Float())
+	case reflect.String:
+		return sign(v1.String() < v2.String(), v1.String() > v2.String())
+	}
+
+	if reflect.DeepEqual(v1.Interface(), v2.Interface()) {
+		return 0
+	}
+
+	return 1
+}
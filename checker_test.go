@@ -0,0 +1,313 @@
+package gmvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requiredAction struct {
+	Name  string   `param:"Query" checker:"required" json:"name"`
+	Note  string   `param:"Query" checker:"required,nullable" json:"note"`
+	Tags  []string `param:"Query" checker:"notempty" default:"x" json:"tags"`
+	Count int      `param:"Query" checker:"required" default:"1" json:"count"`
+}
+
+func (a *requiredAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestRequiredChecker(t *testing.T) {
+	builder := CreateGmvcBuilder()
+
+	ctx := newMockContext(http.MethodGet, "/?Name=a&Note=&Count=0")
+	serve(builder, &requiredAction{}, ctx)
+	assert.JSONEq(t, `{"name":"a","note":"","tags":["x"],"count":0}`, bodyString(ctx))
+
+	// default values are not present
+	ctx = newMockContext(http.MethodGet, "/?Name=a&Note=")
+	serve(builder, &requiredAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "field Count is required")
+
+	// empty values are refused unless nullable
+	ctx = newMockContext(http.MethodGet, "/?Name=&Note=&Count=1")
+	serve(builder, &requiredAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "field Name must not be empty")
+
+	ctx = newMockContext(http.MethodGet, "/?Name=a&Count=1")
+	serve(builder, &requiredAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "field Note is required")
+}
+
+type patchAction struct {
+	Ctx context.Context `json:"-"`
+
+	Name    Optional[string]    `param:"Form" checker:"notempty" json:"name"`
+	Age     Optional[int]       `param:"Form" json:"age"`
+	Birth   Optional[time.Time] `param:"Form" layout:"2006-01-02" json:"birth"`
+	Email   string              `param:"Form" json:"-"`
+	Present map[string]bool     `json:"present"`
+}
+
+func (a *patchAction) Init() error {
+	ctx := a.Ctx.(GmvcContext)
+
+	a.Present = map[string]bool{}
+	for _, field := range []string{"Name", "Age", "Birth", "Email"} {
+		a.Present[field] = ctx.Present(field)
+	}
+
+	return nil
+}
+
+func (a *patchAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestOptional(t *testing.T) {
+	ctx := newMockContext(http.MethodPost, "/")
+	ctx.req.form.Add("Name", "tom")
+	ctx.req.form.Add("Age", "0")
+	serve(CreateGmvcBuilder(), &patchAction{}, ctx)
+	assert.JSONEq(t, `{
+		"present": {"Name": true, "Age": true, "Birth": false, "Email": false},
+		"name": "tom", "age": 0, "birth": null
+	}`, bodyString(ctx))
+
+	ctx = newMockContext(http.MethodPost, "/")
+	ctx.req.form.Add("Name", "tom")
+	ctx.req.form.Add("Birth", "2024-02-01")
+	serve(CreateGmvcBuilder(), &patchAction{}, ctx)
+	assert.Contains(t, bodyString(ctx), `"birth":"2024-02-01T00:00:00Z"`)
+
+	// unset Optional is empty
+	ctx = newMockContext(http.MethodPost, "/")
+	ctx.req.form.Add("Age", "1")
+	serve(CreateGmvcBuilder(), &patchAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "field Name must not be empty")
+
+	var body struct {
+		Name Optional[string] `json:"name"`
+		Age  Optional[int]    `json:"age"`
+		Note Optional[string] `json:"note"`
+	}
+
+	assert.Nil(t, json.Unmarshal([]byte(`{"name":null,"age":3}`), &body))
+	_, nameSet := body.Name.Value()
+	age, ageSet := body.Age.Value()
+	assert.True(t, nameSet)
+	assert.True(t, ageSet)
+	assert.Equal(t, 3, age)
+	assert.False(t, body.Note.IsSet())
+	assert.Equal(t, "n/a", body.Note.OrElse("n/a"))
+
+	data, err := json.Marshal(body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name":"","age":3,"note":null}`, string(data))
+}
+
+type page struct {
+	PageSize int `param:"Query" checker:"positive"`
+}
+
+type crossFieldAction struct {
+	Page page `param:"Recursive"`
+
+	Type     string         `param:"Query"`
+	Card     string         `param:"Query" checker:"required_if(Type,card,debit)"`
+	Start    time.Time      `param:"Query" layout:"2006-01-02"`
+	End      *time.Time     `param:"Query" layout:"2006-01-02" checker:"gtfield(Start)"`
+	Password string         `param:"Query"`
+	Confirm  string         `param:"Query" checker:"eqfield(Password)"`
+	IDs      []int          `param:"Query" checker:"notempty,dive,positive"`
+	Labels   map[string]int `param:"Query,prefix=label_" checker:"dive,positive"`
+}
+
+func (a *crossFieldAction) Validate(ctx GmvcContext) error {
+	if a.Type == "forbidden" {
+		return Forbidden("forbidden type")
+	}
+
+	if a.Type == "invalid" {
+		return errors.New("invalid type")
+	}
+
+	return nil
+}
+
+func (a *crossFieldAction) Go() (interface{}, error) {
+	return "ok", nil
+}
+
+func serveCrossField(target string) *mockContext {
+	builder := CreateGmvcBuilder().
+		RegisterValidator("positive", func(ctx GmvcContext, fieldMeta *ParamMeta, value interface{}) error {
+			if v, _ := value.(int); v <= 0 {
+				return errors.New("must be positive")
+			}
+
+			return nil
+		})
+
+	ctx := newMockContext(http.MethodGet, target)
+	serve(builder, &crossFieldAction{}, ctx)
+	return ctx
+}
+
+func TestCrossFieldChecker(t *testing.T) {
+	ctx := serveCrossField("/?PageSize=10&Type=card&Card=1234&Start=2024-01-01&End=2024-01-02&Password=x&Confirm=x&IDs=1,2&label_a=1")
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, `"ok"`, bodyString(ctx))
+
+	// absent End and Card is not required for other types
+	ctx = serveCrossField("/?PageSize=10&Type=cash&Start=2024-01-01&IDs=1")
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+
+	ctx = serveCrossField("/?PageSize=0&Type=debit&Start=2024-01-02&End=2024-01-01&Password=x&Confirm=y&IDs=1,0,-1&label_b=0&label_a=1")
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.JSONEq(t, `{
+		"code": "validation_failed",
+		"message": "validation failed",
+		"details": [
+			{"field": "PageSize", "message": "must be positive"},
+			{"field": "Card", "message": "field Card is required"},
+			{"field": "End", "message": "field End must be greater than Start"},
+			{"field": "Confirm", "message": "field Confirm must be equal to Password"},
+			{"field": "IDs[1]", "message": "must be positive"},
+			{"field": "IDs[2]", "message": "must be positive"},
+			{"field": "Labels[b]", "message": "must be positive"}
+		]
+	}`, bodyString(ctx))
+}
+
+func TestValidator(t *testing.T) {
+	// Validate runs after the checkers
+	ctx := serveCrossField("/?PageSize=0&Type=invalid&IDs=1")
+	assert.Contains(t, bodyString(ctx), "PageSize")
+	assert.NotContains(t, bodyString(ctx), "invalid type")
+
+	ctx = serveCrossField("/?PageSize=1&Type=invalid&IDs=1")
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.JSONEq(t, `{"code":"validation_failed","message":"validation failed","details":[{"message":"invalid type"}]}`, bodyString(ctx))
+
+	ctx = serveCrossField("/?PageSize=1&Type=forbidden&IDs=1")
+	assert.Equal(t, http.StatusForbidden, ctx.resp.status)
+}
+
+type badCheckerAction struct{}
+
+func (a *badCheckerAction) Go() (interface{}, error) {
+	return nil, nil
+}
+
+type unknownFieldAction struct {
+	badCheckerAction
+	A int `param:"Query" checker:"gtfield(B)"`
+}
+
+type mismatchedTypeAction struct {
+	badCheckerAction
+	A int    `param:"Query" checker:"gtfield(B)"`
+	B string `param:"Query"`
+}
+
+type unorderedAction struct {
+	badCheckerAction
+	A []int `param:"Query" checker:"gtfield(B)"`
+	B []int `param:"Query"`
+}
+
+type diveScalarAction struct {
+	badCheckerAction
+	A int `param:"Query" checker:"dive,notempty"`
+}
+
+type unknownCheckerAction struct {
+	badCheckerAction
+	A int `param:"Query" checker:"between(1,2)"`
+}
+
+func TestCheckerBuildErrors(t *testing.T) {
+	for _, action := range []Action{&unknownFieldAction{}, &mismatchedTypeAction{}, &unorderedAction{}, &diveScalarAction{}, &unknownCheckerAction{}} {
+		assert.Panics(t, func() { CreateGmvcBuilder().BuildAction(action) })
+	}
+}
+
+type presenceOwner struct {
+	Age int `param:"owner_age,Query"`
+}
+
+type presenceAction struct {
+	Ctx context.Context `json:"-"`
+
+	Age   int           `param:"age,Query" checker:"required" json:"age"`
+	Owner presenceOwner `param:"Recursive" json:"-"`
+
+	Present []bool `json:"present"`
+}
+
+func (a *presenceAction) Init() error {
+	ctx := a.Ctx.(GmvcContext)
+	a.Present = []bool{ctx.Present("Age"), ctx.Present("Owner.Age")}
+	return nil
+}
+
+func (a *presenceAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestPresenceRecursive(t *testing.T) {
+	// fields of Recursive structs are present by their path, the same name doesn't satisfy required
+	ctx := newMockContext(http.MethodGet, "/?owner_age=3")
+	serve(CreateGmvcBuilder(), &presenceAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "field age is required")
+
+	ctx = newMockContext(http.MethodGet, "/?owner_age=3&age=4")
+	serve(CreateGmvcBuilder(), &presenceAction{}, ctx)
+	assert.JSONEq(t, `{"age":4,"present":[true,true]}`, bodyString(ctx))
+
+	ctx = newMockContext(http.MethodGet, "/?age=4")
+	serve(CreateGmvcBuilder(), &presenceAction{}, ctx)
+	assert.JSONEq(t, `{"age":4,"present":[true,false]}`, bodyString(ctx))
+}
+
+type nilCheckerAction struct {
+	Age  int    `param:"Query" checker:"present"`
+	Name string `param:"Query" checker:"present" default:"gmvc"`
+}
+
+func (a *nilCheckerAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestCheckerAbsentValue(t *testing.T) {
+	// absent parameters without default are nil to the checkers, not the zero value
+	builder := CreateGmvcBuilder().
+		RegisterValidator("present", func(ctx GmvcContext, fieldMeta *ParamMeta, value interface{}) error {
+			if value == nil {
+				return errors.New("is absent")
+			}
+
+			return nil
+		})
+
+	ctx := newMockContext(http.MethodGet, "/")
+	serve(builder, &nilCheckerAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), "Age")
+	assert.NotContains(t, bodyString(ctx), "Name")
+
+	ctx = newMockContext(http.MethodGet, "/?Age=0")
+	serve(builder, &nilCheckerAction{}, ctx)
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+}
@@ -0,0 +1,176 @@
+package gmvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// BodyDecoder decodes the request body into v, v is always a pointer.
+type BodyDecoder func(body []byte, v any) error
+
+var (
+	_ BodyDecoder = json.Unmarshal
+	_ BodyDecoder = xml.Unmarshal
+	_ BodyDecoder = msgpack.Unmarshal
+	_ BodyDecoder = decodeProtobuf
+)
+
+func decodeProtobuf(body []byte, v any) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+
+	return proto.Unmarshal(body, message)
+}
+
+// decodeBody decodes the body for fields which are neither string nor []byte, by the Content-Type of the request.
+// A [FieldError] is returned if the body is malformed.
+func (gmvc *GmvcBuilder) decodeBody(ctx GmvcContext, fieldMeta *ParamMeta, body []byte) (interface{}, error) {
+	typ := fieldMeta.fieldType.Type
+
+	// 处理body, field为string或者[]byte时，直接赋值，命名类型(如type Raw string)需要转换
+	if typ.Kind() == reflect.String {
+		return reflect.ValueOf(string(body)).Convert(typ).Interface(), nil
+	}
+
+	if typ.Kind() == reflect.Slice && reflect.TypeOf(body).ConvertibleTo(typ) {
+		return reflect.ValueOf(body).Convert(typ).Interface(), nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ctx.HttpRequest().ContentType())
+	if err != nil {
+		return nil, NewHTTPError(http.StatusUnsupportedMediaType, "", "missing or malformed Content-Type").WithCause(err)
+	}
+
+	decoder, ok := gmvc.bodyDecoders[mediaType]
+	if !ok {
+		return nil, NewHTTPError(http.StatusUnsupportedMediaType, "", "unsupported Content-Type "+mediaType)
+	}
+
+	// 指针类型直接分配所指对象，protobuf需要*Message才能解析
+	if typ.Kind() == reflect.Ptr {
+		target := reflect.New(typ.Elem())
+		if err := decoder(body, target.Interface()); err != nil {
+			return nil, &FieldError{Field: fieldMeta.fieldName, Err: err}
+		}
+
+		return target.Interface(), nil
+	}
+
+	target := reflect.New(typ)
+	if err := decoder(body, target.Interface()); err != nil {
+		return nil, &FieldError{Field: fieldMeta.fieldName, Err: err}
+	}
+
+	return target.Elem().Interface(), nil
+}
+
+var _ Responsor = (*XMLResponsor)(nil)
+var _ fallibleResponsor = (*XMLResponsor)(nil)
+var _ selectiveResponsor = (*XMLResponsor)(nil)
+var _ Responsor = (*MsgPackResponsor)(nil)
+var _ Responsor = (*ProtobufResponsor)(nil)
+
+// XMLResponsor xml实现
+type XMLResponsor struct{}
+
+// Response 返回的方法
+func (r *XMLResponsor) Response(ctx GmvcContext, resp *Response) {
+	if err := r.render(ctx, resp); err != nil {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+	}
+}
+
+// render 先编码，失败时不写入任何header
+func (r *XMLResponsor) render(ctx GmvcContext, resp *Response) error {
+	setDefault(resp)
+
+	b, err := xml.Marshal(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	setHeader(ctx, resp)
+	ctx.HttpResponse().SetHeader("Content-Type", "application/xml; charset=utf-8")
+	ctx.HttpResponse().Status(resp.StatusCode)
+	ctx.HttpResponse().Body(bytes.NewReader(append([]byte(xml.Header), b...)))
+	return nil
+}
+
+// accepts encoding/xml不支持map
+func (r *XMLResponsor) accepts(body any) bool {
+	v := reflect.ValueOf(body)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	return v.Kind() != reflect.Map
+}
+
+// MediaTypes 协商用的媒体类型
+func (r *XMLResponsor) MediaTypes() []string {
+	return []string{"application/xml", "text/xml"}
+}
+
+// MsgPackResponsor msgpack实现
+type MsgPackResponsor struct{}
+
+// Response 返回的方法
+func (r *MsgPackResponsor) Response(ctx GmvcContext, resp *Response) {
+	setDefault(resp)
+	setHeader(ctx, resp)
+
+	b, err := msgpack.Marshal(resp.Body)
+	if err != nil {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.HttpResponse().SetHeader("Content-Type", "application/msgpack")
+	ctx.HttpResponse().Status(resp.StatusCode)
+	ctx.HttpResponse().Body(bytes.NewReader(b))
+}
+
+// MediaTypes 协商用的媒体类型
+func (r *MsgPackResponsor) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack"}
+}
+
+// ProtobufResponsor protobuf实现，Response.Body必须为proto.Message
+type ProtobufResponsor struct{}
+
+// Response 返回的方法
+func (r *ProtobufResponsor) Response(ctx GmvcContext, resp *Response) {
+	setDefault(resp)
+	setHeader(ctx, resp)
+
+	message, ok := resp.Body.(proto.Message)
+	if !ok {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	b, err := proto.Marshal(message)
+	if err != nil {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.HttpResponse().SetHeader("Content-Type", "application/x-protobuf")
+	ctx.HttpResponse().Status(resp.StatusCode)
+	ctx.HttpResponse().Body(bytes.NewReader(b))
+}
+
+// MediaTypes 协商用的媒体类型
+func (r *ProtobufResponsor) MediaTypes() []string {
+	return []string{"application/x-protobuf", "application/protobuf"}
+}
@@ -0,0 +1,193 @@
+package gmvc
+
+import (
+	"encoding/xml"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type codecUser struct {
+	XMLName xml.Name `json:"-" xml:"user" msgpack:"-"`
+	Name    string   `json:"name" xml:"name" msgpack:"name"`
+	Age     int      `json:"age" xml:"age" msgpack:"age"`
+}
+
+type codecAction struct {
+	User *codecUser `param:"Body"`
+}
+
+func (a *codecAction) Produces() []RenderType {
+	return []RenderType{JSON, XML, MsgPack}
+}
+
+func (a *codecAction) Go() (interface{}, error) {
+	return a.User, nil
+}
+
+func TestBodyDecoderAndResponsor(t *testing.T) {
+	builder := CreateGmvcBuilder()
+	user := &codecUser{Name: "gmvc", Age: 18}
+	msgpackBody, _ := msgpack.Marshal(user)
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        []byte
+		accept      string
+		want        string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        []byte(`{"name":"gmvc","age":18}`),
+			accept:      "application/json",
+			want:        `{"name":"gmvc","age":18}`,
+		},
+		{
+			name:        "xml",
+			contentType: "application/xml",
+			body:        []byte(`<user><name>gmvc</name><age>18</age></user>`),
+			accept:      "application/xml",
+			want:        xml.Header + `<user><name>gmvc</name><age>18</age></user>`,
+		},
+		{
+			name:        "msgpack",
+			contentType: "application/msgpack",
+			body:        msgpackBody,
+			accept:      "application/x-msgpack",
+			want:        string(msgpackBody),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := newMockContext(http.MethodPost, "/")
+			ctx.req.header.set("Content-Type", c.contentType)
+			ctx.req.header.set("Accept", c.accept)
+			ctx.req.body = c.body
+
+			serve(builder, &codecAction{}, ctx)
+			assert.Equal(t, http.StatusOK, ctx.resp.status)
+			assert.Equal(t, c.want, ctx.resp.body.String())
+		})
+	}
+
+	ctx := newMockContext(http.MethodPost, "/")
+	ctx.req.header.set("Content-Type", "application/json")
+	ctx.req.body = []byte(`{"name":`)
+	serve(builder, &codecAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+
+	ctx = newMockContext(http.MethodPost, "/")
+	ctx.req.header.set("Content-Type", "text/csv")
+	ctx.req.body = []byte(`gmvc,18`)
+	serve(builder, &codecAction{}, ctx)
+	assert.Equal(t, http.StatusUnsupportedMediaType, ctx.resp.status)
+}
+
+type protobufAction struct {
+	Name *wrapperspb.StringValue `param:"Body"`
+}
+
+func (a *protobufAction) Go() (interface{}, error) {
+	return &Response{Render: Protobuf, Body: wrapperspb.String("hello " + a.Name.GetValue())}, nil
+}
+
+func TestProtobuf(t *testing.T) {
+	body, _ := proto.Marshal(wrapperspb.String("gmvc"))
+
+	ctx := newMockContext(http.MethodPost, "/")
+	ctx.req.header.set("Content-Type", "application/x-protobuf")
+	ctx.req.body = body
+	serve(CreateGmvcBuilder(), &protobufAction{}, ctx)
+
+	ret := &wrapperspb.StringValue{}
+	assert.Nil(t, proto.Unmarshal(ctx.resp.body.Bytes(), ret))
+	assert.Equal(t, "hello gmvc", ret.GetValue())
+}
+
+type (
+	rawText  string
+	rawBytes []byte
+)
+
+type rawBodyAction struct {
+	Text  rawText  `param:"Body"`
+	Bytes rawBytes `param:"Body"`
+}
+
+func (a *rawBodyAction) Go() (interface{}, error) {
+	return map[string]string{"text": string(a.Text), "bytes": string(a.Bytes)}, nil
+}
+
+func TestNamedRawBody(t *testing.T) {
+	ctx := newMockContext(http.MethodPost, "/")
+	ctx.req.header.set("Content-Type", "text/plain")
+	ctx.req.body = []byte("hello")
+	serve(CreateGmvcBuilder(), &rawBodyAction{}, ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.JSONEq(t, `{"text":"hello","bytes":"hello"}`, bodyString(ctx))
+}
+
+type xmlMapAction struct {
+	Raw bool `param:"Query"`
+}
+
+func (a *xmlMapAction) Produces() []RenderType {
+	return []RenderType{XML, JSON}
+}
+
+func (a *xmlMapAction) Go() (interface{}, error) {
+	if a.Raw {
+		return &Response{Render: XML, Body: map[string]int{"age": 18}}, nil
+	}
+
+	return map[string]int{"age": 18}, nil
+}
+
+func TestXMLMap(t *testing.T) {
+	builder := CreateGmvcBuilder()
+
+	// maps are never negotiated to XML
+	ctx := newMockContext(http.MethodGet, "/")
+	serve(builder, &xmlMapAction{}, ctx)
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, `{"age":18}`, bodyString(ctx))
+
+	ctx = newMockContext(http.MethodGet, "/")
+	ctx.req.header.set("Accept", "application/xml")
+	serve(builder, &xmlMapAction{}, ctx)
+	assert.Equal(t, http.StatusNotAcceptable, ctx.resp.status)
+
+	// the encoding error is answered by the error handler
+	ctx = newMockContext(http.MethodGet, "/?Raw=true")
+	serve(builder, &xmlMapAction{}, ctx)
+	assert.Equal(t, http.StatusInternalServerError, ctx.resp.status)
+	assert.JSONEq(t, `{"code":"internal_server_error","message":"Internal Server Error"}`, bodyString(ctx))
+}
+
+type htmlAction struct{}
+
+func (a *htmlAction) Go() (interface{}, error) {
+	return map[string]string{"html": "<b>"}, nil
+}
+
+func TestJSONOptions(t *testing.T) {
+	ctx := newMockContext(http.MethodGet, "/")
+	serve(CreateGmvcBuilder(), &htmlAction{}, ctx)
+	assert.Equal(t, `{"html":"\u003cb\u003e"}`, ctx.resp.body.String())
+
+	ctx = newMockContext(http.MethodGet, "/")
+	serve(CreateGmvcBuilder(JSONEscapeHTML(false), JSONIndent("", "  ")), &htmlAction{}, ctx)
+	assert.Equal(t, "{\n  \"html\": \"<b>\"\n}", ctx.resp.body.String())
+
+	ctx = newMockContext(http.MethodGet, "/")
+	serve(CreateGmvcBuilder(JSONMarshaler(func(v any) ([]byte, error) { return []byte("custom"), nil })), &htmlAction{}, ctx)
+	assert.Equal(t, "custom", ctx.resp.body.String())
+}
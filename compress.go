@@ -0,0 +1,351 @@
+package gmvc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Compressor creates the writer compressing to w, for a `Content-Encoding`.
+// If the writer implements `Flush() error`, streamed responses (eg. SSE) are flushed through it.
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+// compressBufferLimit is the max size of the body compressed in memory, larger bodies are compressed while streaming.
+const compressBufferLimit = 1 << 20
+
+var (
+	defaultCompressors = map[string]Compressor{
+		"gzip": func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+		"deflate": func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, flate.DefaultCompression)
+		},
+	}
+
+	// 已经压缩过的媒体类型
+	incompressibleTypes = map[string]struct{}{
+		"application/zip":              {},
+		"application/gzip":             {},
+		"application/x-gzip":           {},
+		"application/x-bzip2":          {},
+		"application/x-7z-compressed":  {},
+		"application/x-rar-compressed": {},
+		"application/pdf":              {},
+		"font/woff":                    {},
+		"font/woff2":                   {},
+	}
+)
+
+// compressFilter compresses the rendered response by the `Accept-Encoding` of the request, see [Compress].
+type compressFilter struct {
+	minSize     int
+	encodings   []string
+	compressors map[string]Compressor
+
+	// fail answers the error of compressing in memory, nothing is written then
+	fail func(ctx GmvcContext, err error)
+}
+
+func newCompressFilter(options compressOptions, fail func(ctx GmvcContext, err error)) *compressFilter {
+	filter := &compressFilter{
+		minSize:     options.minSize,
+		compressors: make(map[string]Compressor),
+		fail:        fail,
+	}
+
+	// 自定义的算法优先于gzip和deflate
+	for _, encoding := range options.encodings {
+		filter.encodings = append(filter.encodings, encoding)
+		filter.compressors[encoding] = options.compressors[encoding]
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if _, ok := filter.compressors[encoding]; !ok {
+			filter.encodings = append(filter.encodings, encoding)
+			filter.compressors[encoding] = defaultCompressors[encoding]
+		}
+	}
+
+	return filter
+}
+
+// FilterRender implements RenderFilter.
+func (f *compressFilter) FilterRender(ctx GmvcContext, result interface{}, render func(out HttpResponse)) {
+	out := ctx.HttpResponse()
+	addVary(out, "Accept-Encoding")
+
+	acceptEncoding, _ := ctx.HttpRequest().Header().Get("Accept-Encoding")
+	encoding := negotiateEncoding(acceptEncoding, f.encodings)
+	if encoding == "" {
+		render(out)
+		return
+	}
+
+	resp := &compressResponse{
+		HttpResponse: out,
+		filter:       f,
+		encoding:     encoding,
+	}
+
+	render(resp)
+
+	// 内存中压缩失败时body还没有写出，去掉压缩相关的header，交给error handler
+	if resp.err != nil && !out.Committed() {
+		out.SetHeader("Content-Encoding", "")
+		out.SetHeader("ETag", "")
+		f.fail(ctx, resp.err)
+		return
+	}
+
+	if err := resp.close(); err != nil {
+		logError(ctx, "gmvc: action %s compress response failed: %v", actionName(ctx), err)
+	}
+}
+
+// negotiateEncoding chooses the encoding of the highest quality, ties are broken by the order of available.
+// It returns "" if none is acceptable, then the response is not compressed.
+func negotiateEncoding(acceptEncoding string, available []string) string {
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(k, "q") {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				} else {
+					q = 0
+				}
+			}
+		}
+
+		qualities[name] = q
+	}
+
+	chosen, best := "", 0.0
+	for _, encoding := range available {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = qualities["*"]
+		}
+
+		if q > best {
+			chosen, best = encoding, q
+		}
+	}
+
+	return chosen
+}
+
+// compressResponse compresses the body written to the underlying HttpResponse.
+type compressResponse struct {
+	HttpResponse
+
+	filter   *compressFilter
+	encoding string
+
+	// writer compressing the body written by Writer, closed after rendering
+	writer io.WriteCloser
+	flush  ResponseWriter
+
+	// err is the error of compressing the body in memory
+	err error
+}
+
+// compressible reports whether the response should be compressed, by the status and the header set so far.
+func (r *compressResponse) compressible() bool {
+	status := r.StatusCode()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified ||
+		status == http.StatusPartialContent {
+		return false
+	}
+
+	header := r.Header()
+	if _, ok := header.Get("Content-Encoding"); ok {
+		return false
+	}
+
+	if _, ok := header.Get("Content-Range"); ok {
+		return false
+	}
+
+	if cacheControl, ok := header.Get("Cache-Control"); ok && strings.Contains(cacheControl, "no-transform") {
+		return false
+	}
+
+	contentType, _ := header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if _, ok := incompressibleTypes[mediaType]; ok {
+		return false
+	}
+
+	typ, _, _ := strings.Cut(mediaType, "/")
+	if typ == "video" || typ == "audio" || (typ == "image" && mediaType != "image/svg+xml") {
+		return false
+	}
+
+	return true
+}
+
+// setEncoding sets the `Content-Encoding`. The strong ETag is made weak, since the compressed body is not byte-for-byte
+// equivalent to the identity one, weak comparison of If-None-Match still matches.
+func (r *compressResponse) setEncoding() {
+	r.SetHeader("Content-Encoding", r.encoding)
+	if etag, ok := r.Header().Get("ETag"); ok && etag != "" && !strings.HasPrefix(etag, "W/") {
+		r.SetHeader("ETag", "W/"+etag)
+	}
+}
+
+// Body compresses in, in memory if the size of in is known, otherwise while streaming.
+// Bodies smaller than the min size are not compressed.
+func (r *compressResponse) Body(in io.Reader) {
+	if !r.compressible() {
+		r.HttpResponse.Body(in)
+		return
+	}
+
+	size := -1
+	if sized, ok := in.(interface{ Len() int }); ok {
+		size = sized.Len()
+	}
+
+	if size >= 0 && size < r.filter.minSize {
+		r.HttpResponse.Body(in)
+		return
+	}
+
+	compressor := r.filter.compressors[r.encoding]
+	if size >= 0 && size <= compressBufferLimit {
+		var buf bytes.Buffer
+		writer, err := compressor(&buf)
+		if err == nil {
+			_, err = io.Copy(writer, in)
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		// in已经读完，例如FileResponse的文件，需要在这里关闭
+		if closer, ok := in.(io.Closer); ok {
+			_ = closer.Close()
+		}
+
+		if err != nil {
+			r.err = err
+			return
+		}
+
+		r.setEncoding()
+		r.HttpResponse.Body(bytes.NewReader(buf.Bytes()))
+		return
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		writer, err := compressor(pw)
+		if err == nil {
+			_, err = io.Copy(writer, in)
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		if closer, ok := in.(io.Closer); ok {
+			_ = closer.Close()
+		}
+
+		_ = pw.CloseWithError(err)
+	}()
+
+	r.setEncoding()
+	r.HttpResponse.Body(pr)
+}
+
+// Writer compresses the body written by the Responsor, Flush flushes the compressor then the underlying writer.
+func (r *compressResponse) Writer() ResponseWriter {
+	if r.flush != nil {
+		return r.flush
+	}
+
+	out := r.HttpResponse.Writer()
+	if !r.compressible() {
+		r.flush = out
+		return out
+	}
+
+	writer, err := r.filter.compressors[r.encoding](out)
+	if err != nil {
+		r.flush = out
+		return out
+	}
+
+	r.setEncoding()
+	r.writer = writer
+	r.flush = &compressWriter{writer: writer, out: out}
+	return r.flush
+}
+
+// close closes the compressor of Writer, writing the remaining data.
+func (r *compressResponse) close() error {
+	if r.writer == nil {
+		return nil
+	}
+
+	if err := r.writer.Close(); err != nil {
+		return err
+	}
+
+	return r.flush.(*compressWriter).out.Flush()
+}
+
+type compressWriter struct {
+	writer io.WriteCloser
+	out    ResponseWriter
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	return w.writer.Write(p)
+}
+
+func (w *compressWriter) Flush() error {
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return w.out.Flush()
+}
+
+// addVary adds the header name to the `Vary` header, keeping the names already there.
+func addVary(out HttpResponse, name string) {
+	vary, ok := out.Header().Get("Vary")
+	if !ok || vary == "" {
+		out.SetHeader("Vary", name)
+		return
+	}
+
+	for _, v := range strings.Split(vary, ",") {
+		if strings.EqualFold(strings.TrimSpace(v), name) {
+			return
+		}
+	}
+
+	out.SetHeader("Vary", vary+", "+name)
+}
@@ -0,0 +1,206 @@
+package gmvc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type compressAction struct {
+	Kind string `param:"kind,Query"`
+}
+
+// compressFile is the content of the "file" kind, to check it's closed.
+var compressFile *closeRecorder
+
+type closeRecorder struct {
+	*strings.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (a *compressAction) Go() (interface{}, error) {
+	switch a.Kind {
+	case "small":
+		return "hi", nil
+	case "stream":
+		// MultiReader hides the size, so the body is compressed while streaming
+		return StreamReader("text/plain", io.MultiReader(strings.NewReader(strings.Repeat("a", 2048)))), nil
+	case "file":
+		return &FileResponse{Content: compressFile, Name: "a.txt"}, nil
+	case "image":
+		return &FileResponse{Content: strings.NewReader(strings.Repeat("a", 2048)), Name: "a.png"}, nil
+	case "sse":
+		ch := make(chan *SSEEvent, 1)
+		ch <- &SSEEvent{Data: "hello"}
+		close(ch)
+		return &SSEResponse{Events: ch}, nil
+	}
+
+	return strings.Repeat("gmvc", 512), nil
+}
+
+func serveCompress(target, acceptEncoding string, options ...GmvcOption) *mockContext {
+	options = append([]GmvcOption{Compress(1024)}, options...)
+	builder := CreateGmvcBuilder(options...)
+
+	ctx := newMockContext(http.MethodGet, target)
+	if acceptEncoding != "" {
+		ctx.req.header.set("Accept-Encoding", acceptEncoding)
+	}
+
+	serve(builder, &compressAction{}, ctx)
+	return ctx
+}
+
+func gunzip(t *testing.T, b []byte) string {
+	reader, err := gzip.NewReader(bytes.NewReader(b))
+	assert.Nil(t, err)
+
+	out, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	return string(out)
+}
+
+func TestCompress(t *testing.T) {
+	ctx := serveCompress("/", "deflate;q=0.5, gzip")
+	encoding, _ := ctx.resp.header.Get("Content-Encoding")
+	vary, _ := ctx.resp.header.Get("Vary")
+	assert.Equal(t, "gzip", encoding)
+	assert.Equal(t, "Accept-Encoding", vary)
+	assert.Equal(t, `"`+strings.Repeat("gmvc", 512)+`"`, gunzip(t, ctx.resp.body.Bytes()))
+
+	ctx = serveCompress("/", "deflate")
+	encoding, _ = ctx.resp.header.Get("Content-Encoding")
+	assert.Equal(t, "deflate", encoding)
+	out, err := io.ReadAll(flate.NewReader(&ctx.resp.body))
+	assert.Nil(t, err)
+	assert.Equal(t, `"`+strings.Repeat("gmvc", 512)+`"`, string(out))
+}
+
+func TestCompressSkipped(t *testing.T) {
+	cases := []struct {
+		name           string
+		target         string
+		acceptEncoding string
+	}{
+		{name: "no accept-encoding", target: "/"},
+		{name: "identity", target: "/", acceptEncoding: "identity, gzip;q=0"},
+		{name: "small body", target: "/?kind=small", acceptEncoding: "gzip"},
+		{name: "compressed media type", target: "/?kind=image", acceptEncoding: "gzip"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := serveCompress(c.target, c.acceptEncoding)
+			_, ok := ctx.resp.header.Get("Content-Encoding")
+			vary, _ := ctx.resp.header.Get("Vary")
+			assert.False(t, ok)
+			assert.Equal(t, "Accept-Encoding", vary)
+		})
+	}
+}
+
+func TestCompressFileClosed(t *testing.T) {
+	// the size of the file is known, it's compressed in memory and closed after reading
+	compressFile = &closeRecorder{Reader: strings.NewReader(strings.Repeat("a", 2048))}
+	ctx := serveCompress("/?kind=file", "gzip")
+	encoding, _ := ctx.resp.header.Get("Content-Encoding")
+	assert.Equal(t, "gzip", encoding)
+	assert.Equal(t, strings.Repeat("a", 2048), gunzip(t, ctx.resp.body.Bytes()))
+	assert.True(t, compressFile.closed)
+}
+
+func TestCompressStream(t *testing.T) {
+	ctx := serveCompress("/?kind=stream", "gzip")
+	encoding, _ := ctx.resp.header.Get("Content-Encoding")
+	assert.Equal(t, "gzip", encoding)
+	assert.Equal(t, strings.Repeat("a", 2048), gunzip(t, ctx.resp.body.Bytes()))
+
+	ctx = serveCompress("/?kind=sse", "gzip")
+	encoding, _ = ctx.resp.header.Get("Content-Encoding")
+	assert.Equal(t, "gzip", encoding)
+	assert.Equal(t, "\ndata: hello\n\n", gunzip(t, ctx.resp.body.Bytes()))
+}
+
+func TestCompressWith(t *testing.T) {
+	identity := func(w io.Writer) (io.WriteCloser, error) {
+		return nopWriteCloser{w}, nil
+	}
+
+	ctx := serveCompress("/", "gzip, custom", CompressWith("custom", identity))
+	encoding, _ := ctx.resp.header.Get("Content-Encoding")
+	assert.Equal(t, "custom", encoding)
+	assert.Equal(t, `"`+strings.Repeat("gmvc", 512)+`"`, bodyString(ctx))
+}
+
+func TestCompressVaryAccept(t *testing.T) {
+	ctx := serveCompress("/", "gzip", Negotiate(JSON))
+	vary, _ := ctx.resp.header.Get("Vary")
+	assert.Equal(t, "Accept-Encoding, Accept", vary)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestCompressETag(t *testing.T) {
+	serveETag := func(acceptEncoding, ifNoneMatch string) *mockContext {
+		ctx := newMockContext(http.MethodGet, "/")
+		ctx.req.header.set("Accept-Encoding", acceptEncoding)
+		if ifNoneMatch != "" {
+			ctx.req.header.set("If-None-Match", ifNoneMatch)
+		}
+
+		serve(CreateGmvcBuilder(Compress(1024)), &compressAction{}, ctx, &ETagMiddleware{})
+		return ctx
+	}
+
+	// the compressed body has the weak etag, the identity body keeps the strong one
+	identity := serveETag("identity", "")
+	strong, _ := identity.resp.header.Get("ETag")
+	assert.Regexp(t, `^"[\w-]+"$`, strong)
+
+	gzipped := serveETag("gzip", "")
+	weak, _ := gzipped.resp.header.Get("ETag")
+	encoding, _ := gzipped.resp.header.Get("Content-Encoding")
+	assert.Equal(t, "gzip", encoding)
+	assert.Equal(t, "W/"+strong, weak)
+
+	notModified := serveETag("gzip", weak)
+	assert.Equal(t, http.StatusNotModified, notModified.resp.status)
+}
+
+type brokenWriteCloser struct{}
+
+func (brokenWriteCloser) Write(p []byte) (int, error) { return 0, errors.New("broken") }
+func (brokenWriteCloser) Close() error                { return nil }
+
+func TestCompressError(t *testing.T) {
+	broken := func(w io.Writer) (io.WriteCloser, error) {
+		return brokenWriteCloser{}, nil
+	}
+
+	// the error is answered by the error handler, without the encoding and the etag of the failed body
+	ctx := newMockContext(http.MethodGet, "/")
+	ctx.req.header.set("Accept-Encoding", "broken")
+	serve(CreateGmvcBuilder(Compress(1024), CompressWith("broken", broken)), &compressAction{}, ctx, &ETagMiddleware{})
+
+	assert.Equal(t, http.StatusInternalServerError, ctx.resp.status)
+	assert.JSONEq(t, `{"code":"internal_server_error","message":"Internal Server Error"}`, bodyString(ctx))
+	_, ok := ctx.resp.header.Get("Content-Encoding")
+	assert.False(t, ok)
+	_, ok = ctx.resp.header.Get("ETag")
+	assert.False(t, ok)
+}
@@ -0,0 +1,345 @@
+package gmvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProvider provides the configuration values bound by the Config source, eg. `param:"db.host,Config"`.
+// Keys are dot separated paths.
+type ConfigProvider interface {
+	Lookup(key string) (string, bool)
+}
+
+// DynamicConfig is implemented by ConfigProviders whose values change at runtime, eg. [FileConfig].
+// Values of dynamic providers are looked up per request, others are looked up once at BuildAction.
+type DynamicConfig interface {
+	Dynamic() bool
+}
+
+// ConfigDecoder decodes the config file into v, which is *map[string]interface{}, eg. json.Unmarshal or toml.Unmarshal.
+type ConfigDecoder func(data []byte, v interface{}) error
+
+var (
+	_ ConfigProvider = MapConfig(nil)
+	_ ConfigProvider = (*EnvConfig)(nil)
+	_ ConfigProvider = (*FileConfig)(nil)
+	_ ConfigProvider = ConfigChain(nil)
+)
+
+// MapConfig provides the config from the map, mostly for tests.
+type MapConfig map[string]string
+
+// Lookup implements ConfigProvider.
+func (c MapConfig) Lookup(key string) (string, bool) {
+	value, ok := c[key]
+	return value, ok
+}
+
+// EnvConfig provides the config from the environment variables, the key is upper-cased, and '.' and '-' are replaced by '_',
+// eg. "db.host" is looked up as "APP_DB_HOST" with the prefix "APP_".
+type EnvConfig struct {
+	Prefix string
+}
+
+// Lookup implements ConfigProvider.
+func (c *EnvConfig) Lookup(key string) (string, bool) {
+	name := strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(key))
+	return os.LookupEnv(c.Prefix + name)
+}
+
+// ConfigChain looks up the providers in order, the first found wins, eg. environment variables overriding the config file.
+type ConfigChain []ConfigProvider
+
+// Lookup implements ConfigProvider.
+func (c ConfigChain) Lookup(key string) (string, bool) {
+	for _, provider := range c {
+		if value, ok := provider.Lookup(key); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// Dynamic implements DynamicConfig, the chain is dynamic if any of the providers is dynamic.
+func (c ConfigChain) Dynamic() bool {
+	for _, provider := range c {
+		if isDynamicConfig(provider) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FileConfig provides the config from JSON or YAML file, or any format decoded by the ConfigDecoder.
+// Nested objects are flattened into dot separated keys, eg. {"db": {"host": "x"}} into "db.host",
+// arrays of scalars are joined by ',' so that they can be bound to slices, elements are also keyed by index, eg. "hosts.0".
+// FileConfig is dynamic, it can be reloaded by [FileConfig.Reload] or [FileConfig.Watch].
+type FileConfig struct {
+	path   string
+	decode ConfigDecoder
+
+	mu      sync.RWMutex
+	values  map[string]string
+	modTime time.Time
+}
+
+// NewFileConfig loads the config file, the format is decided by the extension (.json, .yaml or .yml) if decode is nil.
+func NewFileConfig(path string, decode ConfigDecoder) (*FileConfig, error) {
+	if decode == nil {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			decode = decodeJSONConfig
+		case ".yaml", ".yml":
+			decode = yaml.Unmarshal
+		default:
+			return nil, fmt.Errorf("gmvc: unknown config format of %s, a ConfigDecoder is needed", path)
+		}
+	}
+
+	c := &FileConfig{path: path, decode: decode}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Lookup implements ConfigProvider.
+func (c *FileConfig) Lookup(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	value, ok := c.values[key]
+	return value, ok
+}
+
+// Dynamic implements DynamicConfig.
+func (c *FileConfig) Dynamic() bool {
+	return true
+}
+
+// Reload reloads the config file, the values are kept if it fails.
+func (c *FileConfig) Reload() error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	if err := c.decode(data, &raw); err != nil {
+		return fmt.Errorf("gmvc: decode config %s: %w", c.path, err)
+	}
+
+	values := make(map[string]string)
+	flattenConfig("", raw, values)
+
+	c.mu.Lock()
+	c.values = values
+	c.modTime = info.ModTime()
+	c.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the config file when its modification time changes, until the ctx is done. Errors are logged.
+//
+//	go config.Watch(ctx, 5*time.Second)
+func (c *FileConfig) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(c.path)
+		if err != nil {
+			logError(ctx, "gmvc: watch config %s: %v", c.path, err)
+			continue
+		}
+
+		c.mu.RLock()
+		changed := !info.ModTime().Equal(c.modTime)
+		c.mu.RUnlock()
+
+		if changed {
+			if err := c.Reload(); err != nil {
+				logError(ctx, "gmvc: reload config %s: %v", c.path, err)
+			}
+		}
+	}
+}
+
+// decodeJSONConfig decodes numbers as json.Number, so that integers are not formatted as floats.
+func decodeJSONConfig(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func flattenConfig(prefix string, value interface{}, values map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for key, elem := range v {
+			flattenConfig(join(key), elem, values)
+		}
+	case map[interface{}]interface{}:
+		for key, elem := range v {
+			flattenConfig(join(fmt.Sprint(key)), elem, values)
+		}
+	case []interface{}:
+		scalars := make([]string, 0, len(v))
+		for i, elem := range v {
+			flattenConfig(join(fmt.Sprint(i)), elem, values)
+
+			switch reflect.ValueOf(elem).Kind() {
+			case reflect.Map, reflect.Slice, reflect.Invalid:
+			default:
+				scalars = append(scalars, fmt.Sprint(elem))
+			}
+		}
+
+		if len(scalars) == len(v) {
+			values[prefix] = strings.Join(scalars, sliceSplit)
+		}
+	default:
+		values[prefix] = fmt.Sprint(v)
+	}
+}
+
+func isDynamicConfig(provider ConfigProvider) bool {
+	dynamic, ok := provider.(DynamicConfig)
+	return ok && dynamic.Dynamic()
+}
+
+// configValue is the value of the Config source looked up at BuildAction, for static providers.
+type configValue struct {
+	value string
+	found bool
+}
+
+// lookupConfig looks up the names of the field in the provider.
+func (gmvc *GmvcBuilder) lookupConfig(fieldMeta *ParamMeta) (string, bool) {
+	if fieldMeta.config != nil {
+		return fieldMeta.config.value, fieldMeta.config.found
+	}
+
+	for _, name := range fieldMeta.names {
+		if value, ok := gmvc.options.config.Lookup(name); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// prepareConfig checks the field bound from the Config source, and looks up the value once if the provider is static.
+// Values which can't be converted are reported at BuildAction.
+func (gmvc *GmvcBuilder) prepareConfig(structMeta *ActionMeta, fieldMeta *ParamMeta) {
+	if !hasSourceTag(fieldMeta.source, ConfigSrc) {
+		return
+	}
+
+	provider := gmvc.options.config
+	if provider == nil {
+		panic(fmt.Sprintf("gmvc: field %s.%s is bound from Config, but no ConfigProvider is used, see UseConfig",
+			structMeta.handlerName, fieldMeta.fieldType.Name))
+	}
+
+	if isDynamicConfig(provider) {
+		return
+	}
+
+	value, found := gmvc.lookupConfig(fieldMeta)
+	fieldMeta.config = &configValue{value: value, found: found}
+
+	if !found || fieldMeta.resolver != nil || fieldMeta.nested || fieldMeta.prefix != "" {
+		return
+	}
+
+	if _, ok := gmvc.typedResolver[fieldMeta.fieldType.Type]; ok {
+		return
+	}
+
+	if _, err := gmvc.convertFieldValue(nil, fieldMeta, value, structMeta.handlerName); err != nil {
+		panic(fmt.Sprintf("gmvc: config of field %s.%s: %v", structMeta.handlerName, fieldMeta.fieldType.Name, err))
+	}
+}
+
+// configure sets the fields bound from the Config source of the singleton, once when it's registered.
+func (gmvc *GmvcBuilder) configure(obj interface{}) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct || !hasConfigTag(v.Elem().Type()) {
+		return
+	}
+
+	if gmvc.options.config == nil {
+		panic(fmt.Sprintf("gmvc: singleton %s is bound from Config, but no ConfigProvider is used, see UseConfig",
+			v.Elem().Type().Name()))
+	}
+
+	meta := gmvc.introspect(v.Elem())
+	for i, fieldMeta := range meta.fieldList {
+		if !hasSourceTag(fieldMeta.source, ConfigSrc) || !v.Elem().Field(i).CanSet() {
+			continue
+		}
+
+		origin, found := gmvc.lookupConfig(fieldMeta)
+		if !found {
+			if !fieldMeta.hasDefault {
+				continue
+			}
+
+			origin = fieldMeta.def
+		}
+
+		value, err := gmvc.convertFieldValue(nil, fieldMeta, origin, meta.handlerName)
+		if err != nil {
+			panic(fmt.Sprintf("gmvc: config of field %s.%s: %v", meta.handlerName, fieldMeta.fieldType.Name, err))
+		}
+
+		v.Elem().Field(i).Set(reflect.ValueOf(value))
+	}
+}
+
+// hasConfigTag reports whether any field of the struct is bound from the Config source.
+func hasConfigTag(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		for _, value := range splitParam(typ.Field(i).Tag.Get(XParam)) {
+			if value == XConfig {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,121 @@
+package gmvc
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type configAction struct {
+	Host    string        `param:"db.host,Config" json:"host"`
+	Port    int           `param:"db.port,Config" json:"port"`
+	Tags    []string      `param:"tags,Config" json:"tags"`
+	Timeout time.Duration `param:"timeout,Query,Config" default:"5s" json:"timeout"`
+}
+
+func (a *configAction) Go() (interface{}, error) {
+	return a, nil
+}
+
+func TestConfigMap(t *testing.T) {
+	config := MapConfig{"db.host": "localhost", "db.port": "3306", "tags": "a,b", "timeout": "1s"}
+	builder := CreateGmvcBuilder(UseConfig(config))
+	handler := builder.BuildAction(&configAction{})
+
+	// static config is looked up once at BuildAction
+	config["db.host"] = "changed"
+
+	ctx := newMockContext(http.MethodGet, "/")
+	handler(ctx)
+	assert.JSONEq(t, `{"host":"localhost","port":3306,"tags":["a","b"],"timeout":1000000000}`, bodyString(ctx))
+
+	// query takes precedence over config
+	ctx = newMockContext(http.MethodGet, "/?timeout=2s")
+	handler(ctx)
+	assert.JSONEq(t, `{"host":"localhost","port":3306,"tags":["a","b"],"timeout":2000000000}`, bodyString(ctx))
+}
+
+func TestConfigEnv(t *testing.T) {
+	t.Setenv("APP_DB_HOST", "db.internal")
+	t.Setenv("APP_DB_PORT", "5432")
+
+	config := ConfigChain{&EnvConfig{Prefix: "APP_"}, MapConfig{"db.host": "localhost", "tags": "x"}}
+	ctx := newMockContext(http.MethodGet, "/")
+	serve(CreateGmvcBuilder(UseConfig(config)), &configAction{}, ctx)
+	assert.JSONEq(t, `{"host":"db.internal","port":5432,"tags":["x"],"timeout":5000000000}`, bodyString(ctx))
+}
+
+func TestConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte("db:\n  host: yaml-host\n  port: 3306\ntags: [a, b]\n"), 0o600))
+
+	config, err := NewFileConfig(path, nil)
+	assert.Nil(t, err)
+
+	handler := CreateGmvcBuilder(UseConfig(config)).BuildAction(&configAction{})
+	ctx := newMockContext(http.MethodGet, "/")
+	handler(ctx)
+	assert.JSONEq(t, `{"host":"yaml-host","port":3306,"tags":["a","b"],"timeout":5000000000}`, bodyString(ctx))
+
+	// file config is dynamic, reloaded values are used by the following requests
+	assert.Nil(t, os.WriteFile(path, []byte("db:\n  host: reloaded\n  port: 3307\n"), 0o600))
+	assert.Nil(t, config.Reload())
+
+	ctx = newMockContext(http.MethodGet, "/")
+	handler(ctx)
+	assert.JSONEq(t, `{"host":"reloaded","port":3307,"tags":null,"timeout":5000000000}`, bodyString(ctx))
+
+	jsonPath := filepath.Join(dir, "app.json")
+	assert.Nil(t, os.WriteFile(jsonPath, []byte(`{"db":{"port":12345678},"servers":[{"name":"s1"}]}`), 0o600))
+	config, err = NewFileConfig(jsonPath, nil)
+	assert.Nil(t, err)
+
+	port, _ := config.Lookup("db.port")
+	name, _ := config.Lookup("servers.0.name")
+	assert.Equal(t, "12345678", port)
+	assert.Equal(t, "s1", name)
+
+	_, err = NewFileConfig(filepath.Join(dir, "app.toml"), nil)
+	assert.NotNil(t, err)
+}
+
+type configService struct {
+	Host string `param:"db.host,Config"`
+	Port int    `param:"db.port,Config" default:"3306"`
+}
+
+type configServiceAction struct {
+	Service *configService `autowire:"service"`
+}
+
+func (a *configServiceAction) Go() (interface{}, error) {
+	return a.Service, nil
+}
+
+func TestConfigSingleton(t *testing.T) {
+	builder := CreateGmvcBuilder(UseConfig(MapConfig{"db.host": "localhost"}))
+
+	service := &configService{}
+	builder.RegisterSingleton("service", service)
+	assert.Equal(t, "localhost", service.Host)
+	assert.Equal(t, 3306, service.Port)
+
+	// autowired instances of the action are configured too
+	ctx := newMockContext(http.MethodGet, "/")
+	serve(builder, &configServiceAction{Service: &configService{}}, ctx)
+	assert.JSONEq(t, `{"Host":"localhost","Port":3306}`, bodyString(ctx))
+}
+
+func TestConfigBuildErrors(t *testing.T) {
+	assert.Panics(t, func() { CreateGmvcBuilder().BuildAction(&configAction{}) })
+	assert.Panics(t, func() { CreateGmvcBuilder().RegisterSingleton("service", &configService{}) })
+	assert.NotPanics(t, func() { CreateGmvcBuilder().RegisterSingleton("action", &configServiceAction{}) })
+	assert.Panics(t, func() {
+		CreateGmvcBuilder(UseConfig(MapConfig{"db.port": "not a number"})).BuildAction(&configAction{})
+	})
+}
@@ -1,7 +1,5 @@
 package gmvc
 
-import "math"
-
 type Src int
 
 const (
@@ -14,12 +12,27 @@ const (
 	// XValidator 指定参数绑定的验证器，参数解析成功后，会挨个执行验证器，验证器return错误则直接返回http
 	XValidator = "checker"
 
+	// XRequired 内置验证器，参数必须在请求中出现，default值不算，空值需要同时指定nullable，例如 checker:"required,nullable"
+	XRequired = "required"
+
+	// XNotEmpty 内置验证器，参数的值不能为空，例如空字符串、空slice、nil指针
+	XNotEmpty = "notempty"
+
+	// XNullable 允许required的参数为空值，例如 ?name=
+	XNullable = "nullable"
+
+	// XDive 之后的验证器检查slice、array、map的每个元素，例如 checker:"notempty,dive,notempty"
+	XDive = "dive"
+
 	// XDefault 参数没传情况下的默认值
 	XDefault = "default"
 
 	// XResolver 参数自定义解析插件
 	XResolver = "resolver"
 
+	// XLayout 时间参数的格式，例如 layout:"2006-01-02"，默认RFC3339，unix和unixmilli表示Unix时间戳
+	XLayout = "layout"
+
 	// XAutowire 申明依赖,当前默认singleton
 	XAutowire = "autowire"
 
@@ -42,11 +55,36 @@ const (
 	XCtx = "Ctx"
 
 	// XAuto 遍历header、query、body、path、ctx，看是否有匹配的参数，就近原则，以第一个匹配的为主
+	// 来源及顺序由DefineAuto定义，也可以为字段单独指定，例如 param:"Auto(Path,Query)"
 	XAuto = "Auto"
 
 	// XRecursive 递归解析
 	XRecursive = "Recursive"
 
+	// XSep slice字段的分隔符，例如 param:"ids,Query,sep=|"，sep=none表示不分隔，默认为英文逗号
+	XSep = "sep="
+
+	// XSepNone 不分隔slice字段的值
+	XSepNone = "none"
+
+	// XName 参数名称以及别名，别名用|分隔，依次查找，例如 param:"Query,name=user_id|userId"
+	XName = "name="
+
+	// XAliasSplit 参数别名的分隔符
+	XAliasSplit = "|"
+
+	// XPrefix map字段收集key以prefix开头的参数，key去掉prefix，例如 param:"Header,prefix=X-Meta-"
+	XPrefix = "prefix="
+
+	// XPrincipal 绑定认证后的Principal，见AuthMiddleware
+	XPrincipal = "Principal"
+
+	// XCookie 从cookie取参数
+	XCookie = "Cookie"
+
+	// XConfig 从配置取参数，见UseConfig
+	XConfig = "Config"
+
 	// DefaultSrc 参数来源
 	DefaultSrc Src = -1
 
@@ -70,6 +108,16 @@ const (
 	// application/x-www-form-urlencoded
 	FormSrc Src = 1 << 5
 
+	// PrincipalSrc 参数来源，认证后的Principal
+	PrincipalSrc Src = 1 << 6
+
+	// CookieSrc 参数来源
+	CookieSrc Src = 1 << 7
+
+	// ConfigSrc 参数来源，UseConfig指定的ConfigProvider
+	ConfigSrc Src = 1 << 8
+
 	// Any 参数来源
-	AnySrc Src = math.MaxInt32 ^ BodySrc // 异或BodySrc，默认排除从body整体读取
+	// 默认排除从body整体读取，以及Principal、Cookie和Config（只能显式绑定）
+	AnySrc Src = HeaderSrc | QuerySrc | PathSrc | CtxSrc | FormSrc
 )
@@ -1,13 +1,32 @@
 package gmvc
 
 import (
+	"encoding"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	sliceSplit = ","
+
+	// LayoutUnix 时间字段的layout，Unix秒
+	LayoutUnix = "unix"
+
+	// LayoutUnixMilli 时间字段的layout，Unix毫秒
+	LayoutUnixMilli = "unixmilli"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	// 通过RegisterConverter注册的转换方法，reflect.Type -> StringConvert[any]
+	converters sync.Map
 )
 
 // StringConvert converts string to type T.
@@ -149,6 +168,33 @@ var (
 )
 
 func Convert(origin string, target reflect.Type) (interface{}, error) {
+	return convert(origin, target, "")
+}
+
+// RegisterConverter registers the converter of type T, which is used by [Convert] for T, *T, []T and []*T.
+// It takes precedence over the builtin conversions, eg.
+//
+//	gmvc.RegisterConverter(func(s string) (uuid.UUID, error) { return uuid.Parse(s) })
+//
+// Converters should be registered before the actions are built.
+func RegisterConverter[T any](converter StringConvert[T]) {
+	converters.Store(reflect.TypeOf((*T)(nil)).Elem(), convertAnyRet(converter))
+}
+
+// convert converts the origin to the target type, layout is used by time.Time, see [LayoutUnix].
+func convert(origin string, target reflect.Type, layout string) (interface{}, error) {
+	if value, ok, err := convertValue(origin, target, layout); ok {
+		if err != nil {
+			return nil, err
+		}
+
+		return value.Interface(), nil
+	}
+
+	if !isConvertible(target) {
+		return nil, fmt.Errorf("unsupported type %s", target)
+	}
+
 	isarray := target.Kind() == reflect.Slice
 	if isarray {
 		target = target.Elem()
@@ -174,6 +220,172 @@ func Convert(origin string, target reflect.Type) (interface{}, error) {
 	return convertMap[target.Kind()][3](origin)
 }
 
+// convertValue converts the origin by the converter of the type, or the element of the pointer and slice types.
+// It returns false if the type has no converter, then the builtin conversions are used.
+func convertValue(origin string, typ reflect.Type, layout string) (reflect.Value, bool, error) {
+	if converter := lookupConverter(typ, layout); converter != nil {
+		value, err := converter(origin)
+		if err != nil {
+			return reflect.Value{}, true, err
+		}
+
+		return reflect.ValueOf(value), true, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer:
+		elem, ok, err := convertValue(origin, typ.Elem(), layout)
+		if !ok || err != nil {
+			return reflect.Value{}, ok, err
+		}
+
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, true, nil
+	case reflect.Slice:
+		if !hasConverter(typ.Elem()) {
+			return reflect.Value{}, false, nil
+		}
+
+		parts := strings.Split(origin, sliceSplit)
+		ret := reflect.MakeSlice(typ, 0, len(parts))
+		for _, part := range parts {
+			elem, _, err := convertValue(part, typ.Elem(), layout)
+			if err != nil {
+				return reflect.Value{}, true, err
+			}
+
+			ret = reflect.Append(ret, elem)
+		}
+
+		return ret, true, nil
+	}
+
+	return reflect.Value{}, false, nil
+}
+
+// hasConverter reports whether the type, or the element of the pointer type, has a converter other than the builtin ones.
+func hasConverter(typ reflect.Type) bool {
+	if lookupConverter(typ, "") != nil {
+		return true
+	}
+
+	return typ.Kind() == reflect.Pointer && hasConverter(typ.Elem())
+}
+
+// lookupConverter returns the converter of the type in order of: registered converters, time.Time, time.Duration,
+// Optional and encoding.TextUnmarshaler, e.g. net.IP.
+func lookupConverter(typ reflect.Type, layout string) StringConvert[any] {
+	if converter, ok := converters.Load(typ); ok {
+		return converter.(StringConvert[any])
+	}
+
+	switch {
+	case typ == timeType:
+		return func(s string) (interface{}, error) {
+			return parseTime(s, layout)
+		}
+	case typ == durationType:
+		return convertAnyRet(time.ParseDuration)
+	case reflect.PointerTo(typ).Implements(optionalType):
+		return func(s string) (interface{}, error) {
+			return convertOptional(s, typ, layout)
+		}
+	case reflect.PointerTo(typ).Implements(textUnmarshalerType):
+		return func(s string) (interface{}, error) {
+			ptr := reflect.New(typ)
+			if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return nil, err
+			}
+
+			return ptr.Elem().Interface(), nil
+		}
+	}
+
+	return nil
+}
+
+// parseTime parses the time by the layout, RFC3339 by default.
+func parseTime(s string, layout string) (time.Time, error) {
+	switch layout {
+	case "":
+		return time.Parse(time.RFC3339, s)
+	case LayoutUnix, LayoutUnixMilli:
+		value, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s time %q", layout, s)
+		}
+
+		if layout == LayoutUnix {
+			return time.Unix(value, 0), nil
+		}
+
+		return time.UnixMilli(value), nil
+	}
+
+	return time.Parse(layout, s)
+}
+
+// convertValues converts the values of a slice field, each value is split by the separator of the field,
+// eg. "?id=1,2&id=3" is converted to []int{1, 2, 3}.
+func convertValues(fieldMeta *ParamMeta, values []string) (interface{}, error) {
+	return convertSlice(fieldMeta.fieldType.Type, fieldMeta.sep, fieldMeta.layout, values)
+}
+
+// convertSlice converts the values to the slice type, each value is split by the separator if it's not empty.
+func convertSlice(typ reflect.Type, sep string, layout string, values []string) (interface{}, error) {
+	ret := reflect.MakeSlice(typ, 0, len(values))
+	for _, value := range values {
+		parts := []string{value}
+		if sep != "" {
+			parts = strings.Split(value, sep)
+		}
+
+		for _, part := range parts {
+			elem, err := convert(part, typ.Elem(), layout)
+			if err != nil {
+				return nil, err
+			}
+
+			// 自定义类型，例如 type Status string
+			ret = reflect.Append(ret, reflect.ValueOf(elem).Convert(typ.Elem()))
+		}
+	}
+
+	return ret.Interface(), nil
+}
+
+// isConvertible reports whether the type can be converted by [Convert].
+func isConvertible(typ reflect.Type) bool {
+	if hasConverter(typ) {
+		return true
+	}
+
+	if typ.Kind() == reflect.Slice {
+		if hasConverter(typ.Elem()) {
+			return true
+		}
+
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	_, ok := convertMap[typ.Kind()]
+	return ok
+}
+
+// cutPrefix returns s without the prefix and true, or s and false if s doesn't start with the prefix.
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+
+	return s[len(prefix):], true
+}
+
 func convertSliceRet[T any](converter StringConvert[T]) StringConvert[[]T] {
 	return func(s string) ([]T, error) {
 		strs := strings.Split(s, sliceSplit)
@@ -1,8 +1,12 @@
 package gmvc
 
 import (
+	"errors"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -374,3 +378,67 @@ func TestConvertor(t *testing.T) {
 func ptr[T any](v T) *T {
 	return &v
 }
+
+type testCurrency struct {
+	Code string
+}
+
+func TestConvertExtended(t *testing.T) {
+	RegisterConverter(func(s string) (testCurrency, error) {
+		if len(s) != 3 {
+			return testCurrency{}, errors.New("invalid currency")
+		}
+
+		return testCurrency{Code: strings.ToUpper(s)}, nil
+	})
+
+	moment := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		name   string
+		origin string
+		typ    any
+		want   any
+	}{
+		{name: "time", origin: "2024-01-02T03:04:05Z", typ: time.Time{}, want: moment},
+		{name: "time point", origin: "2024-01-02T03:04:05Z", typ: ptr(time.Time{}), want: ptr(moment)},
+		{name: "duration", origin: "1m30s", typ: time.Duration(0), want: 90 * time.Second},
+		{name: "duration slice", origin: "1s,2m", typ: []time.Duration{}, want: []time.Duration{time.Second, 2 * time.Minute}},
+		{name: "text unmarshaler", origin: "127.0.0.1", typ: net.IP{}, want: net.ParseIP("127.0.0.1")},
+		{name: "registered", origin: "usd", typ: testCurrency{}, want: testCurrency{Code: "USD"}},
+		{name: "registered slice of pointer", origin: "usd,eur", typ: []*testCurrency{}, want: []*testCurrency{{Code: "USD"}, {Code: "EUR"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ret, err := Convert(c.origin, reflect.TypeOf(c.typ))
+			assert.Nil(t, err)
+			assert.Equal(t, c.want, ret)
+		})
+	}
+
+	_, err := Convert("dollar", reflect.TypeOf(testCurrency{}))
+	assert.NotNil(t, err)
+}
+
+func TestConvertTimeLayout(t *testing.T) {
+	cases := []struct {
+		layout string
+		origin string
+		want   time.Time
+	}{
+		{layout: "2006-01-02", origin: "2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{layout: LayoutUnix, origin: "1700000000", want: time.Unix(1700000000, 0)},
+		{layout: LayoutUnixMilli, origin: "1700000000123", want: time.UnixMilli(1700000000123)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.layout, func(t *testing.T) {
+			ret, err := convert(c.origin, reflect.TypeOf(time.Time{}), c.layout)
+			assert.Nil(t, err)
+			assert.True(t, c.want.Equal(ret.(time.Time)))
+		})
+	}
+
+	_, err := convert("yesterday", reflect.TypeOf(time.Time{}), LayoutUnix)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,226 @@
+package gmvc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCookie means the cookie value is malformed, or it is not signed (encrypted) by any of the keys.
+	ErrInvalidCookie = errors.New("invalid cookie")
+
+	// ErrCookieExpired means the cookie value is older than [SecureCookie.MaxAge].
+	ErrCookieExpired = errors.New("cookie expired")
+)
+
+// SecureCookie signs or encrypts cookie values, so that the client can't forge them.
+// The first key is used for new values, all the keys are tried when reading, so keys can be rotated by
+// prepending a new key and removing the oldest one once the cookies signed by it have expired.
+type SecureCookie struct {
+	// MaxAge rejects values signed (encrypted) earlier than it, not checked if zero.
+	MaxAge time.Duration
+
+	keys [][]byte
+	now  func() time.Time
+}
+
+// NewSecureCookie creates the SecureCookie with the keys, newest first.
+// Keys should be random bytes, at least 32 bytes long.
+func NewSecureCookie(keys ...[]byte) *SecureCookie {
+	if len(keys) == 0 {
+		panic("gmvc: SecureCookie needs at least one key")
+	}
+
+	return &SecureCookie{keys: keys, now: time.Now}
+}
+
+// Sign signs the value of the named cookie, the value is readable by the client.
+// The result is "base64(value).timestamp.base64(mac)".
+func (s *SecureCookie) Sign(name, value string) string {
+	ts := strconv.FormatInt(s.now().Unix(), 10)
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(value))
+	mac := s.mac(s.keys[0], name, encoded, ts)
+	return encoded + "." + ts + "." + base64.RawURLEncoding.EncodeToString(mac)
+}
+
+// Verify returns the value signed by [SecureCookie.Sign] for the named cookie.
+func (s *SecureCookie) Verify(name, signed string) (string, error) {
+	parts := strings.Split(signed, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: malformed value", ErrInvalidCookie)
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed signature", ErrInvalidCookie)
+	}
+
+	matched := false
+	for _, key := range s.keys {
+		if hmac.Equal(mac, s.mac(key, name, parts[0], parts[1])) {
+			matched = true
+			break
+		}
+	}
+
+	if !matched {
+		return "", fmt.Errorf("%w: signature mismatch", ErrInvalidCookie)
+	}
+
+	ts, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed timestamp", ErrInvalidCookie)
+	}
+
+	if err := s.checkAge(ts); err != nil {
+		return "", err
+	}
+
+	value, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed value", ErrInvalidCookie)
+	}
+
+	return string(value), nil
+}
+
+// Encrypt encrypts the value of the named cookie by AES-GCM, the value is neither readable nor forgeable by the client.
+func (s *SecureCookie) Encrypt(name, value string) (string, error) {
+	aead, err := s.aead(s.keys[0])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	plaintext := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(plaintext, uint64(s.now().Unix()))
+	plaintext = append(plaintext, value...)
+
+	// cookie名称作为附加数据，避免密文被挪用到其它cookie
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the value encrypted by [SecureCookie.Encrypt] for the named cookie.
+func (s *SecureCookie) Decrypt(name, encrypted string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed value", ErrInvalidCookie)
+	}
+
+	for _, key := range s.keys {
+		aead, err := s.aead(key)
+		if err != nil {
+			return "", err
+		}
+
+		if len(sealed) < aead.NonceSize() {
+			return "", fmt.Errorf("%w: malformed value", ErrInvalidCookie)
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+		if err != nil || len(plaintext) < 8 {
+			continue
+		}
+
+		if err := s.checkAge(int64(binary.BigEndian.Uint64(plaintext))); err != nil {
+			return "", err
+		}
+
+		return string(plaintext[8:]), nil
+	}
+
+	return "", fmt.Errorf("%w: decryption failed", ErrInvalidCookie)
+}
+
+// VerifyResolver returns the Resolver binding the value signed by [SecureCookie.Sign], eg.
+//
+//	builder.RegisterResolver("signed", secure.VerifyResolver())
+//	UserID int64 `param:"uid,Cookie" resolver:"signed"`
+//
+// Forged or expired values are rejected as [BindingError].
+func (s *SecureCookie) VerifyResolver() Resolver {
+	return s.resolver(s.Verify)
+}
+
+// DecryptResolver returns the Resolver binding the value encrypted by [SecureCookie.Encrypt], see [SecureCookie.VerifyResolver].
+func (s *SecureCookie) DecryptResolver() Resolver {
+	return s.resolver(s.Decrypt)
+}
+
+func (s *SecureCookie) resolver(open func(name, value string) (string, error)) Resolver {
+	return func(ctx GmvcContext, fieldMeta *ParamMeta, origin string) (interface{}, error) {
+		value, err := open(cookieName(ctx, fieldMeta, origin), origin)
+		if err == nil {
+			var converted interface{}
+			if converted, err = Convert(value, fieldMeta.fieldType.Type); err == nil {
+				return converted, nil
+			}
+		}
+
+		return nil, &BindingError{Fields: []*FieldError{{Field: fieldMeta.fieldName, Err: err}}}
+	}
+}
+
+// cookieName returns the name of the cookie the value is bound from, the value is signed (encrypted) under it.
+// The names and aliases are looked up in order like binding, the field name is returned if the value is not from a cookie.
+func cookieName(ctx GmvcContext, fieldMeta *ParamMeta, origin string) string {
+	req := ctx.HttpRequest()
+	for _, name := range fieldMeta.names {
+		value, ok := req.GetCookie(name)
+		if !ok {
+			// FoldCase时请求中的名称大小写可能不同
+			var key string
+			if key, ok = foldKey(req, fieldMeta, CookieSrc, name); ok {
+				name = key
+				value, _ = req.GetCookie(key)
+			}
+		}
+
+		if ok && value == origin {
+			return name
+		}
+	}
+
+	return fieldMeta.fieldName
+}
+
+func (s *SecureCookie) mac(key []byte, name, value, ts string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name + "|" + value + "|" + ts))
+	return h.Sum(nil)
+}
+
+// aead derives the AES-256 key from the key by SHA-256, so keys of any length can be used.
+func (s *SecureCookie) aead(key []byte) (cipher.AEAD, error) {
+	derived := sha256.Sum256(key)
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (s *SecureCookie) checkAge(ts int64) error {
+	if s.MaxAge > 0 && s.now().Sub(time.Unix(ts, 0)) > s.MaxAge {
+		return ErrCookieExpired
+	}
+
+	return nil
+}
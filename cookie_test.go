@@ -0,0 +1,125 @@
+package gmvc
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cookieAction struct {
+	Theme  string `param:"theme,Cookie"`
+	Lang   string `param:"lang,Cookie,Query"`
+	UserID int64  `param:"uid,Cookie" resolver:"signed"`
+	Cart   string `param:"cart,Cookie" resolver:"encrypted"`
+}
+
+func (a *cookieAction) Go() (interface{}, error) {
+	return &Response{
+		Body: map[string]interface{}{"theme": a.Theme, "lang": a.Lang, "uid": a.UserID, "cart": a.Cart},
+		Cookies: []*http.Cookie{
+			{Name: "theme", Value: "dark", Path: "/", HttpOnly: true},
+			{Name: "seen", Value: "1"},
+		},
+	}, nil
+}
+
+func TestCookie(t *testing.T) {
+	secure := NewSecureCookie([]byte("new-key"), []byte("old-key"))
+	old := NewSecureCookie([]byte("old-key"))
+
+	builder := CreateGmvcBuilder()
+	builder.RegisterResolver("signed", secure.VerifyResolver())
+	builder.RegisterResolver("encrypted", secure.DecryptResolver())
+
+	cart, err := secure.Encrypt("cart", "apple,pear")
+	assert.Nil(t, err)
+
+	ctx := newMockContext(http.MethodGet, "/?lang=en")
+	// signed by the rotated key is still accepted
+	ctx.req.header.set("Cookie", "theme=light; lang=zh; uid="+old.Sign("uid", "42")+"; cart="+cart)
+	serve(builder, &cookieAction{}, ctx)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.JSONEq(t, `{"theme":"light","lang":"en","uid":42,"cart":"apple,pear"}`, bodyString(ctx))
+	assert.Equal(t, []string{"theme=dark; Path=/; HttpOnly", "seen=1"}, ctx.resp.header["Set-Cookie"])
+}
+
+type cookieAliasAction struct {
+	UserID int64 `param:"Cookie,name=uid|user_id" resolver:"signed"`
+}
+
+func (a *cookieAliasAction) Go() (interface{}, error) {
+	return a.UserID, nil
+}
+
+func TestCookieAlias(t *testing.T) {
+	secure := NewSecureCookie([]byte("key"))
+	builder := CreateGmvcBuilder()
+	builder.RegisterResolver("signed", secure.VerifyResolver())
+
+	// the value is verified under the name of the cookie it's bound from
+	ctx := newMockContext(http.MethodGet, "/")
+	ctx.req.header.set("Cookie", "user_id="+secure.Sign("user_id", "42"))
+	serve(builder, &cookieAliasAction{}, ctx)
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, "42", bodyString(ctx))
+
+	// signed under another alias is rejected
+	ctx = newMockContext(http.MethodGet, "/")
+	ctx.req.header.set("Cookie", "user_id="+secure.Sign("uid", "42"))
+	serve(builder, &cookieAliasAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+}
+
+func TestCookieForged(t *testing.T) {
+	secure := NewSecureCookie([]byte("key"))
+	builder := CreateGmvcBuilder()
+	builder.RegisterResolver("signed", secure.VerifyResolver())
+	builder.RegisterResolver("encrypted", secure.DecryptResolver())
+
+	forged := NewSecureCookie([]byte("forged")).Sign("uid", "1")
+	ctx := newMockContext(http.MethodGet, "/")
+	ctx.req.header.set("Cookie", "uid="+forged)
+	serve(builder, &cookieAction{}, ctx)
+
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.True(t, strings.Contains(bodyString(ctx), "uid"))
+}
+
+func TestSecureCookie(t *testing.T) {
+	secure := NewSecureCookie([]byte("key"))
+	now := time.Unix(1700000000, 0)
+	secure.now = func() time.Time { return now }
+	secure.MaxAge = time.Hour
+
+	signed := secure.Sign("session", "id=1")
+	value, err := secure.Verify("session", signed)
+	assert.Nil(t, err)
+	assert.Equal(t, "id=1", value)
+
+	// bound to the cookie name
+	_, err = secure.Verify("other", signed)
+	assert.True(t, errors.Is(err, ErrInvalidCookie))
+
+	encrypted, err := secure.Encrypt("session", "id=1")
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(encrypted, "id=1"))
+
+	value, err = secure.Decrypt("session", encrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, "id=1", value)
+
+	_, err = secure.Decrypt("other", encrypted)
+	assert.True(t, errors.Is(err, ErrInvalidCookie))
+
+	now = now.Add(2 * time.Hour)
+	_, err = secure.Verify("session", signed)
+	assert.Equal(t, ErrCookieExpired, err)
+
+	_, err = secure.Decrypt("session", encrypted)
+	assert.Equal(t, ErrCookieExpired, err)
+}
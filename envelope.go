@@ -0,0 +1,120 @@
+package gmvc
+
+import "net/http"
+
+// Envelope wraps the bodies of the responses in a uniform structure, see [UseEnvelope].
+// Only bodies rendered by JSON, XML and MsgPack are wrapped.
+type Envelope interface {
+	// Success wraps the result of the Action. A nil result is still answered with 204 No Content.
+	Success(ctx GmvcContext, data interface{}) interface{}
+
+	// Failure wraps the error, the status of the response is decided by [HandleError].
+	Failure(ctx GmvcContext, err error) interface{}
+}
+
+// EnvelopeSkipper is implemented by Actions whose results are not wrapped by the Envelope, eg. file downloads.
+// Errors of the Action are not wrapped either.
+type EnvelopeSkipper interface {
+	SkipEnvelope() bool
+}
+
+// EnvelopeBody is the body wrapped by [CodeEnvelope].
+type EnvelopeBody struct {
+	Code    int         `json:"code" xml:"code" msgpack:"code"`
+	Message string      `json:"message" xml:"message" msgpack:"message"`
+	Data    interface{} `json:"data,omitempty" xml:"data,omitempty" msgpack:"data,omitempty"`
+}
+
+var _ Envelope = (*CodeEnvelope)(nil)
+
+// CodeEnvelope wraps successes as {"code":0,"message":"ok","data":...}, and errors as {"code":N,"message":...}.
+type CodeEnvelope struct {
+	// Code maps the error to the code, the HTTP status of [AsHTTPError] by default.
+	Code func(err error) int
+}
+
+// Success implements Envelope.
+func (e *CodeEnvelope) Success(ctx GmvcContext, data interface{}) interface{} {
+	return &EnvelopeBody{Code: 0, Message: "ok", Data: data}
+}
+
+// Failure implements Envelope.
+func (e *CodeEnvelope) Failure(ctx GmvcContext, err error) interface{} {
+	httpErr := AsHTTPError(err)
+	code := httpErr.Status
+	if e.Code != nil {
+		code = e.Code(err)
+	}
+
+	return &EnvelopeBody{Code: code, Message: httpErr.Message, Data: httpErr.Details}
+}
+
+// enveloped reports whether the body rendered by the RenderType is wrapped by the Envelope.
+func (gmvc *GmvcBuilder) enveloped(ctx GmvcContext, render RenderType) bool {
+	if gmvc.options.envelope == nil {
+		return false
+	}
+
+	if render != JSON && render != XML && render != MsgPack {
+		return false
+	}
+
+	skipper, ok := ctx.Action().(EnvelopeSkipper)
+	return !ok || !skipper.SkipEnvelope()
+}
+
+// handleError converts the error to a response by [HandleError], the body is wrapped by the Envelope.
+// The error response is marked as Raw so that it is never wrapped again, values other than Response
+// returned by HandleError are replaced by the failure of the Envelope.
+func (gmvc *GmvcBuilder) handleError(ctx GmvcContext, err error) interface{} {
+	resp := gmvc.errHandler(ctx, err)
+	if gmvc.options.envelope == nil {
+		return resp
+	}
+
+	var entity *Response
+	switch v := resp.(type) {
+	case Response:
+		entity = &v
+	case *Response:
+		copied := *v
+		entity = &copied
+	case nil:
+		return resp
+	default:
+		// 其他类型的返回值按JSON渲染，状态码由AsHTTPError决定，body替换为Envelope的错误结构
+		if !gmvc.enveloped(ctx, JSON) {
+			return resp
+		}
+
+		return &Response{
+			Render:     JSON,
+			Body:       gmvc.options.envelope.Failure(ctx, err),
+			StatusCode: AsHTTPError(err).Status,
+			Raw:        true,
+		}
+	}
+
+	if !entity.Raw && gmvc.enveloped(ctx, entity.Render) {
+		entity.Body = gmvc.options.envelope.Failure(ctx, err)
+	}
+
+	entity.Raw = true
+	return entity
+}
+
+// envelopeSuccess wraps the body of the Response returned by the Action, unless it is Raw.
+func (gmvc *GmvcBuilder) envelopeSuccess(ctx GmvcContext, resp *Response) *Response {
+	if resp.Raw || !gmvc.enveloped(ctx, resp.Render) {
+		return resp
+	}
+
+	copied := *resp
+	copied.Body = gmvc.options.envelope.Success(ctx, resp.Body)
+	copied.Raw = true
+	if copied.StatusCode == 0 {
+		copied.StatusCode = http.StatusOK
+	}
+
+	return &copied
+}
@@ -0,0 +1,105 @@
+package gmvc
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errOutOfStock = errors.New("out of stock")
+
+type envelopeAction struct {
+	Kind string `param:"kind,Query"`
+}
+
+func (a *envelopeAction) Go() (interface{}, error) {
+	switch a.Kind {
+	case "error":
+		return nil, NotFound("item not found")
+	case "business":
+		return nil, errOutOfStock
+	case "response":
+		return &Response{StatusCode: http.StatusCreated, Body: map[string]int{"id": 1}}, nil
+	case "raw":
+		return &Response{Body: map[string]int{"id": 1}, Raw: true}, nil
+	case "string":
+		return &Response{Render: String, Body: "pong"}, nil
+	case "nil":
+		return nil, nil
+	}
+
+	return map[string]string{"name": "gmvc"}, nil
+}
+
+type skipEnvelopeAction struct {
+	Kind string `param:"kind,Query"`
+}
+
+func (a *skipEnvelopeAction) Go() (interface{}, error) {
+	return (&envelopeAction{Kind: a.Kind}).Go()
+}
+
+func (a *skipEnvelopeAction) SkipEnvelope() bool { return true }
+
+func serveEnvelope(action Action, target string) *mockContext {
+	builder := CreateGmvcBuilder(UseEnvelope(&CodeEnvelope{
+		Code: func(err error) int {
+			if errors.Is(err, errOutOfStock) {
+				return 10001
+			}
+
+			return AsHTTPError(err).Status
+		},
+	}))
+
+	ctx := newMockContext(http.MethodGet, target)
+	serve(builder, action, ctx)
+	return ctx
+}
+
+func TestEnvelope(t *testing.T) {
+	cases := []struct {
+		target string
+		status int
+		want   string
+	}{
+		{target: "/", status: http.StatusOK, want: `{"code":0,"message":"ok","data":{"name":"gmvc"}}`},
+		{target: "/?kind=response", status: http.StatusCreated, want: `{"code":0,"message":"ok","data":{"id":1}}`},
+		{target: "/?kind=error", status: http.StatusNotFound, want: `{"code":404,"message":"item not found"}`},
+		{target: "/?kind=business", status: http.StatusInternalServerError, want: `{"code":10001,"message":"Internal Server Error"}`},
+		{target: "/?kind=raw", status: http.StatusOK, want: `{"id":1}`},
+		{target: "/?kind=string", status: http.StatusOK, want: `pong`},
+		{target: "/?kind=nil", status: http.StatusNoContent, want: ``},
+	}
+
+	for _, c := range cases {
+		t.Run(c.target, func(t *testing.T) {
+			ctx := serveEnvelope(&envelopeAction{}, c.target)
+			assert.Equal(t, c.status, ctx.resp.status)
+			assert.Equal(t, c.want, bodyString(ctx))
+		})
+	}
+}
+
+func TestEnvelopeSkipped(t *testing.T) {
+	ctx := serveEnvelope(&skipEnvelopeAction{}, "/")
+	assert.Equal(t, `{"name":"gmvc"}`, bodyString(ctx))
+
+	ctx = serveEnvelope(&skipEnvelopeAction{}, "/?kind=error")
+	assert.JSONEq(t, `{"code":"not_found","message":"item not found"}`, bodyString(ctx))
+}
+
+func TestEnvelopeCustomErrorHandler(t *testing.T) {
+	// values other than Response returned by the HandleError are errors, never wrapped as successes
+	builder := CreateGmvcBuilder(UseEnvelope(&CodeEnvelope{})).
+		SetErrorHandler(func(ctx GmvcContext, err error) interface{} {
+			return map[string]string{"error": "boom"}
+		})
+
+	ctx := newMockContext(http.MethodGet, "/?kind=error")
+	serve(builder, &envelopeAction{}, ctx)
+	assert.Equal(t, http.StatusNotFound, ctx.resp.status)
+	assert.JSONEq(t, `{"code":404,"message":"item not found"}`, bodyString(ctx))
+}
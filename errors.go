@@ -0,0 +1,297 @@
+package gmvc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HTTPError is an error carrying the HTTP status and a machine readable code.
+// Return it from anywhere in gmvc (Checker, Resolver, Init, Go, Middleware) to control the error response.
+type HTTPError struct {
+	// Status is the HTTP status code.
+	Status int
+
+	// Code is the machine readable error code, eg. "not_found".
+	Code string
+
+	// Message is the human readable message, which is safe to show to the client.
+	Message string
+
+	// Details holds any extra information for the client.
+	Details any
+
+	// Cause is the underlying error, which is never rendered to the client.
+	Cause error
+}
+
+// NewHTTPError creates an [HTTPError].
+// If code is empty, it is derived from the status, eg. 404 -> "not_found".
+// If message is empty, the status text is used.
+func NewHTTPError(status int, code, message string) *HTTPError {
+	if code == "" {
+		code = statusCode(status)
+	}
+
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	return &HTTPError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Cause
+}
+
+// WithDetails returns a copy of the error with the details.
+func (e *HTTPError) WithDetails(details any) *HTTPError {
+	ret := *e
+	ret.Details = details
+	return &ret
+}
+
+// WithCause returns a copy of the error with the cause.
+func (e *HTTPError) WithCause(err error) *HTTPError {
+	ret := *e
+	ret.Cause = err
+	return &ret
+}
+
+// BadRequest creates a 400 [HTTPError].
+func BadRequest(message string) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, "", message)
+}
+
+// Unauthorized creates a 401 [HTTPError].
+func Unauthorized(message string) *HTTPError {
+	return NewHTTPError(http.StatusUnauthorized, "", message)
+}
+
+// Forbidden creates a 403 [HTTPError].
+func Forbidden(message string) *HTTPError {
+	return NewHTTPError(http.StatusForbidden, "", message)
+}
+
+// NotFound creates a 404 [HTTPError].
+func NotFound(message string) *HTTPError {
+	return NewHTTPError(http.StatusNotFound, "", message)
+}
+
+// MethodNotAllowed creates a 405 [HTTPError].
+func MethodNotAllowed(message string) *HTTPError {
+	return NewHTTPError(http.StatusMethodNotAllowed, "", message)
+}
+
+// NotAcceptable creates a 406 [HTTPError].
+func NotAcceptable(message string) *HTTPError {
+	return NewHTTPError(http.StatusNotAcceptable, "", message)
+}
+
+// Conflict creates a 409 [HTTPError].
+func Conflict(message string) *HTTPError {
+	return NewHTTPError(http.StatusConflict, "", message)
+}
+
+// UnprocessableEntity creates a 422 [HTTPError].
+func UnprocessableEntity(message string) *HTTPError {
+	return NewHTTPError(http.StatusUnprocessableEntity, "", message)
+}
+
+// TooManyRequests creates a 429 [HTTPError].
+func TooManyRequests(message string) *HTTPError {
+	return NewHTTPError(http.StatusTooManyRequests, "", message)
+}
+
+// InternalServerError creates a 500 [HTTPError].
+func InternalServerError(message string) *HTTPError {
+	return NewHTTPError(http.StatusInternalServerError, "", message)
+}
+
+// ServiceUnavailable creates a 503 [HTTPError].
+func ServiceUnavailable(message string) *HTTPError {
+	return NewHTTPError(http.StatusServiceUnavailable, "", message)
+}
+
+// FieldError describes the failure of a single Action field.
+type FieldError struct {
+	// Field is the parameter name of the field, empty if the error is of the whole Action, see [Validator].
+	Field string
+
+	// Value is the origin value got from the HTTP request, if any.
+	Value string
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("field %s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON renders the field error without leaking the origin value.
+func (e *FieldError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Field   string `json:"field,omitempty"`
+		Message string `json:"message"`
+	}{
+		Field:   e.Field,
+		Message: e.Err.Error(),
+	})
+}
+
+// BindingError is returned when parameters can not be converted to the type of the Action fields.
+type BindingError struct {
+	Fields []*FieldError
+}
+
+func (e *BindingError) Error() string {
+	return "binding failed: " + joinFieldErrors(e.Fields)
+}
+
+// ValidationError is returned when parameters are refused by the Checkers.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed: " + joinFieldErrors(e.Fields)
+}
+
+// asValidationError wraps the error returned by [Validator] into ValidationError, HTTPError and ValidationError are kept.
+func asValidationError(err error) error {
+	var httpErr *HTTPError
+	var validationErr *ValidationError
+	if errors.As(err, &httpErr) || errors.As(err, &validationErr) {
+		return err
+	}
+
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return &ValidationError{Fields: []*FieldError{fieldErr}}
+	}
+
+	return &ValidationError{Fields: []*FieldError{{Err: err}}}
+}
+
+func joinFieldErrors(fields []*FieldError) string {
+	msgs := make([]string, 0, len(fields))
+	for _, field := range fields {
+		msgs = append(msgs, field.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// ErrorBody is the JSON body rendered by [DefaultErrorHandler].
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// AsHTTPError converts any error to an [HTTPError]:
+//   - [HTTPError] is returned as is.
+//   - [BindingError] becomes 400 "invalid_parameter".
+//   - [ValidationError] becomes 400 "validation_failed".
+//   - [AuthError] becomes 401 or 403, with the status text as the message.
+//   - any other error becomes 500 without exposing its message.
+func AsHTTPError(err error) *HTTPError {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	var bindingErr *BindingError
+	if errors.As(err, &bindingErr) {
+		return NewHTTPError(http.StatusBadRequest, "invalid_parameter", "invalid parameters").
+			WithDetails(bindingErr.Fields).
+			WithCause(err)
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return NewHTTPError(http.StatusBadRequest, "validation_failed", "validation failed").
+			WithDetails(validationErr.Fields).
+			WithCause(err)
+	}
+
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		// 不返回认证失败的原因，例如JWT签名错误、后端错误
+		return NewHTTPError(authErr.Status, "", "").WithCause(err)
+	}
+
+	return InternalServerError("").WithCause(err)
+}
+
+// DefaultErrorHandler renders errors as JSON [ErrorBody] with the status of [AsHTTPError].
+// Errors resulting in 5xx are logged, except [PanicError] which has been logged with its stack.
+func DefaultErrorHandler(ctx GmvcContext, err error) interface{} {
+	httpErr := AsHTTPError(err)
+	if httpErr.Status >= http.StatusInternalServerError {
+		logServerError(ctx, err)
+	}
+
+	return &Response{
+		StatusCode: httpErr.Status,
+		Render:     JSON,
+		Body: ErrorBody{
+			Code:    httpErr.Code,
+			Message: httpErr.Message,
+			Details: httpErr.Details,
+		},
+	}
+}
+
+// statusCode derives the error code from the status text, eg. 404 -> "not_found".
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+
+	text = strings.ToLower(text)
+	text = strings.ReplaceAll(text, "-", " ")
+	text = strings.ReplaceAll(text, "'", "")
+	return strings.Join(strings.Fields(text), "_")
+}
+
+func logServerError(ctx GmvcContext, err error) {
+	var perr *PanicError
+	if errors.As(err, &perr) {
+		return
+	}
+
+	logError(ctx, "gmvc: action %s failed: %v", actionName(ctx), err)
+}
+
+func actionName(ctx GmvcContext) string {
+	if meta := ctx.ActionMeta(); meta != nil {
+		return meta.GetName()
+	}
+
+	return ""
+}
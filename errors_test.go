@@ -0,0 +1,95 @@
+package gmvc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type errorAction struct {
+	Age  int    `param:"Query"`
+	Kind string `param:"Query"`
+}
+
+func (a *errorAction) Go() (interface{}, error) {
+	switch a.Kind {
+	case "http":
+		return nil, NotFound("user not found")
+	case "unknown":
+		return nil, errors.New("dial tcp: connection refused")
+	}
+
+	return a.Age, nil
+}
+
+func TestDefaultErrorHandler(t *testing.T) {
+	builder := CreateGmvcBuilder()
+
+	cases := []struct {
+		name   string
+		target string
+		status int
+		code   string
+	}{
+		{
+			name:   "ok",
+			target: "/?Age=1",
+			status: http.StatusOK,
+		},
+		{
+			name:   "binding error",
+			target: "/?Age=abc",
+			status: http.StatusBadRequest,
+			code:   "invalid_parameter",
+		},
+		{
+			name:   "http error",
+			target: "/?Kind=http",
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		{
+			name:   "unknown error",
+			target: "/?Kind=unknown",
+			status: http.StatusInternalServerError,
+			code:   "internal_server_error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := newMockContext(http.MethodGet, c.target)
+			serve(builder, &errorAction{}, ctx)
+
+			assert.Equal(t, c.status, ctx.resp.status)
+			if c.code == "" {
+				return
+			}
+
+			body := ErrorBody{}
+			assert.Nil(t, json.Unmarshal(ctx.resp.body.Bytes(), &body))
+			assert.Equal(t, c.code, body.Code)
+			assert.NotContains(t, body.Message, "connection refused")
+		})
+	}
+}
+
+func TestAsHTTPError(t *testing.T) {
+	err := AsHTTPError(&BindingError{Fields: []*FieldError{{Field: "Age", Value: "abc", Err: errors.New("invalid syntax")}}})
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+
+	b, _ := json.Marshal(err.Details)
+	assert.JSONEq(t, `[{"field":"Age","message":"invalid syntax"}]`, string(b))
+
+	err = AsHTTPError(unauthorized(fmt.Errorf("%w: signature mismatch", ErrInvalidToken)))
+	assert.Equal(t, http.StatusUnauthorized, err.Status)
+	assert.Equal(t, "unauthorized", err.Code)
+	assert.Equal(t, "Unauthorized", err.Message)
+	assert.True(t, errors.Is(err.Unwrap(), ErrInvalidToken))
+
+	assert.Equal(t, "im_a_teapot", statusCode(http.StatusTeapot))
+}
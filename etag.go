@@ -0,0 +1,110 @@
+package gmvc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// ETagger is implemented by Actions which know the version of the resource, eg. a revision or an update time.
+// The ETag is derived from the version instead of hashing the rendered body.
+type ETagger interface {
+	ETag() string
+}
+
+// CacheController is implemented by Actions which declare the `Cache-Control` of their successful responses,
+// eg. "public, max-age=60".
+type CacheController interface {
+	CacheControl() string
+}
+
+var (
+	_ IMiddleware  = (*ETagMiddleware)(nil)
+	_ RenderFilter = (*ETagMiddleware)(nil)
+)
+
+// ETagMiddleware sets the `ETag` of successful GET and HEAD responses,
+// and answers `304 Not Modified` without the body if the `If-None-Match` header matches.
+// The rendered body is buffered to compute the ETag, streams, SSE and files are left untouched.
+type ETagMiddleware struct {
+	BaseMiddleware
+
+	// Weak generates weak ETags, eg. W/"xyz", for responses which are semantically but not byte-for-byte equivalent.
+	Weak bool
+
+	// CacheControl is the default `Cache-Control`, overridden by the Action implementing [CacheController].
+	CacheControl string
+}
+
+// NewETagMiddleware creates the ETagMiddleware.
+func NewETagMiddleware(weak bool) *ETagMiddleware {
+	return &ETagMiddleware{Weak: weak}
+}
+
+// IsApply implements IMiddleware.
+func (m *ETagMiddleware) IsApply(ctx GmvcContext) bool {
+	method := ctx.HttpRequest().Method()
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// FilterRender implements RenderFilter.
+func (m *ETagMiddleware) FilterRender(ctx GmvcContext, result interface{}, render func(out HttpResponse)) {
+	out := ctx.HttpResponse()
+	switch result.(type) {
+	case *Stream, *SSEResponse, *FileResponse:
+		render(out)
+		return
+	}
+
+	buf := NewBufferedResponse(out)
+	render(buf)
+
+	if buf.Forwarded() || buf.StatusCode() != http.StatusOK {
+		buf.WriteTo(out)
+		return
+	}
+
+	if cacheControl := m.cacheControl(ctx); cacheControl != "" {
+		buf.SetHeader("Cache-Control", cacheControl)
+	}
+
+	// Responsor设置的ETag优先
+	etag, ok := buf.Header().Get("ETag")
+	if !ok {
+		etag = m.etag(ctx, buf.Bytes())
+		buf.SetHeader("ETag", etag)
+	}
+
+	if inm, ok := ctx.HttpRequest().Header().Get("If-None-Match"); ok && etagMatch(inm, etag, false) {
+		buf.Status(http.StatusNotModified)
+		buf.WriteHeaderTo(out)
+		return
+	}
+
+	buf.WriteTo(out)
+}
+
+func (m *ETagMiddleware) cacheControl(ctx GmvcContext) string {
+	if controller, ok := ctx.Action().(CacheController); ok {
+		return controller.CacheControl()
+	}
+
+	return m.CacheControl
+}
+
+func (m *ETagMiddleware) etag(ctx GmvcContext, body []byte) string {
+	var tag string
+	if etagger, ok := ctx.Action().(ETagger); ok {
+		tag = strings.Trim(strings.TrimPrefix(etagger.ETag(), "W/"), `"`)
+	} else {
+		sum := sha256.Sum256(body)
+		tag = base64.RawURLEncoding.EncodeToString(sum[:16])
+	}
+
+	if m.Weak {
+		return `W/"` + tag + `"`
+	}
+
+	return `"` + tag + `"`
+}
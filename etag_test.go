@@ -0,0 +1,114 @@
+package gmvc
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type etagAction struct {
+	Fail bool `param:"fail,Query"`
+}
+
+func (a *etagAction) Go() (interface{}, error) {
+	if a.Fail {
+		return nil, errors.New("boom")
+	}
+
+	return map[string]string{"name": "gmvc"}, nil
+}
+
+type versionedAction struct {
+	etagAction
+}
+
+func (a *versionedAction) ETag() string         { return "v42" }
+func (a *versionedAction) CacheControl() string { return "public, max-age=60" }
+
+func serveETag(action Action, method, target string, header map[string]string) *mockContext {
+	ctx := newMockContext(method, target)
+	for k, v := range header {
+		ctx.req.header.set(k, v)
+	}
+
+	serve(CreateGmvcBuilder(), action, ctx, &ETagMiddleware{CacheControl: "no-cache"})
+	return ctx
+}
+
+func TestETagMiddleware(t *testing.T) {
+	ctx := serveETag(&etagAction{}, http.MethodGet, "/", nil)
+	etag, _ := ctx.resp.header.Get("ETag")
+	cacheControl, _ := ctx.resp.header.Get("Cache-Control")
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, `{"name":"gmvc"}`, bodyString(ctx))
+	assert.Regexp(t, `^"[\w-]+"$`, etag)
+	assert.Equal(t, "no-cache", cacheControl)
+
+	// the same body has the same etag
+	again := serveETag(&etagAction{}, http.MethodGet, "/", nil)
+	etag2, _ := again.resp.header.Get("ETag")
+	assert.Equal(t, etag, etag2)
+
+	notModified := serveETag(&etagAction{}, http.MethodGet, "/", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, notModified.resp.status)
+	assert.Equal(t, 0, notModified.resp.body.Len())
+
+	modified := serveETag(&etagAction{}, http.MethodGet, "/", map[string]string{"If-None-Match": `"stale"`})
+	assert.Equal(t, http.StatusOK, modified.resp.status)
+	assert.Equal(t, `{"name":"gmvc"}`, bodyString(modified))
+}
+
+func TestETagMiddlewareVersion(t *testing.T) {
+	ctx := serveETag(&versionedAction{}, http.MethodGet, "/", map[string]string{"If-None-Match": `W/"v42"`})
+	etag, _ := ctx.resp.header.Get("ETag")
+	cacheControl, _ := ctx.resp.header.Get("Cache-Control")
+	assert.Equal(t, http.StatusNotModified, ctx.resp.status)
+	assert.Equal(t, `"v42"`, etag)
+	assert.Equal(t, "public, max-age=60", cacheControl)
+}
+
+func TestETagMiddlewareSkipped(t *testing.T) {
+	// errors are not cached
+	ctx := serveETag(&etagAction{}, http.MethodGet, "/?fail=true", nil)
+	_, ok := ctx.resp.header.Get("ETag")
+	assert.Equal(t, http.StatusInternalServerError, ctx.resp.status)
+	assert.False(t, ok)
+
+	// only GET and HEAD
+	ctx = serveETag(&etagAction{}, http.MethodPost, "/", nil)
+	_, ok = ctx.resp.header.Get("ETag")
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.False(t, ok)
+}
+
+type etagCookieAction struct{}
+
+func (a *etagCookieAction) Go() (interface{}, error) {
+	return &Response{
+		Body:    "ok",
+		Cookies: []*http.Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}},
+	}, nil
+}
+
+func TestETagMiddlewareCookies(t *testing.T) {
+	ctx := serveETag(&etagCookieAction{}, http.MethodGet, "/", nil)
+	cookies, _ := ctx.resp.header.Gets("Set-Cookie")
+	assert.Equal(t, []string{"a=1", "b=2"}, cookies)
+}
+
+func TestBufferedResponseMultiValues(t *testing.T) {
+	ctx := newMockContext(http.MethodGet, "/")
+	buffered := NewBufferedResponse(ctx.resp)
+	http.Header(buffered.header).Add("Set-Cookie", "a=1")
+	http.Header(buffered.header).Add("Set-Cookie", "b=2; Path=/")
+	http.Header(buffered.header).Add("Link", "</a.css>; rel=preload")
+	http.Header(buffered.header).Add("Link", "</b.js>; rel=preload")
+	buffered.WriteHeaderTo(ctx.resp)
+
+	cookies, _ := ctx.resp.header.Gets("Set-Cookie")
+	link, _ := ctx.resp.header.Get("Link")
+	assert.Equal(t, []string{"a=1", "b=2; Path=/"}, cookies)
+	assert.Equal(t, "</a.css>; rel=preload, </b.js>; rel=preload", link)
+}
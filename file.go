@@ -0,0 +1,515 @@
+package gmvc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileResponse is returned from [Action.Go] to download a file.
+// Either Path or Content must be set.
+// Range requests and conditional requests (If-None-Match, If-Modified-Since, If-Range) are supported.
+type FileResponse struct {
+	// Path of the file to send.
+	Path string
+
+	// Content to send if Path is empty, it is closed after writing if it is an io.Closer.
+	Content io.ReadSeeker
+
+	// Name is the filename of the `Content-Disposition` header, the base of Path by default.
+	Name string
+
+	// ModTime sets the `Last-Modified` header, the modification time of the file at Path by default.
+	ModTime time.Time
+
+	// ETag sets the `ETag` header, eg. `"v1"`.
+	ETag string
+
+	// ContentType is detected by the extension of Name, or by the content if the extension is unknown.
+	ContentType string
+
+	// Inline displays the file in the browser instead of downloading it.
+	Inline bool
+
+	// Header is merged into the response header.
+	Header map[string]string
+}
+
+var _ Responsor = (*FileResponsor)(nil)
+
+// FileResponsor 文件下载实现，Response.Body必须为*FileResponse
+type FileResponsor struct{}
+
+// Response 返回的方法
+func (r *FileResponsor) Response(ctx GmvcContext, resp *Response) {
+	file, ok := resp.Body.(*FileResponse)
+	if !ok || (file.Path == "" && file.Content == nil) {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	content, name, modtime, err := file.open()
+	if err != nil {
+		logError(ctx, "gmvc: action %s open file failed: %v", actionName(ctx), err)
+		if errors.Is(err, os.ErrNotExist) {
+			ctx.HttpResponse().Status(http.StatusNotFound)
+		} else {
+			ctx.HttpResponse().Status(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	// 没有写出body时，直接关闭文件
+	body := &fileBody{reader: content, closer: toCloser(content)}
+	defer func() {
+		if body != nil {
+			_ = body.Close()
+		}
+	}()
+
+	size, err := content.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = content.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	contentType, err := file.contentType(content, name)
+	if err != nil {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	setHeader(ctx, resp)
+	out := ctx.HttpResponse()
+	out.SetHeader("Accept-Ranges", "bytes")
+	if file.ETag != "" {
+		out.SetHeader("ETag", file.ETag)
+	}
+
+	if !isZeroTime(modtime) {
+		out.SetHeader("Last-Modified", modtime.UTC().Format(http.TimeFormat))
+	}
+
+	req := ctx.HttpRequest()
+	if status, done := checkPreconditions(req, file.ETag, modtime); done {
+		out.Status(status)
+		return
+	}
+
+	disposition := "attachment"
+	if file.Inline {
+		disposition = "inline"
+	}
+
+	if name != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": name})
+	}
+
+	out.SetHeader("Content-Disposition", disposition)
+
+	status, length := http.StatusOK, size
+	rangeHeader, _ := req.Header().Get("Range")
+	if rangeHeader != "" && req.Method() == http.MethodGet && checkIfRange(req, file.ETag, modtime) {
+		ranges, err := parseRange(rangeHeader, size)
+		if err != nil {
+			out.SetHeader("Content-Range", fmt.Sprintf("bytes */%d", size))
+			out.Status(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+
+		// 请求的范围总和超过文件大小时忽略Range，避免被恶意放大
+		if sumRangesSize(ranges) <= size {
+			status = http.StatusPartialContent
+			switch {
+			case len(ranges) == 1:
+				ra := ranges[0]
+				if _, err := content.Seek(ra.start, io.SeekStart); err != nil {
+					out.Status(http.StatusRequestedRangeNotSatisfiable)
+					return
+				}
+
+				length = ra.length
+				body.reader = io.LimitReader(content, ra.length)
+				out.SetHeader("Content-Range", ra.contentRange(size))
+			default:
+				pr, pw := io.Pipe()
+				mw := multipart.NewWriter(pw)
+				done := make(chan struct{})
+				go func(contentType string) {
+					defer close(done)
+					writeRanges(mw, pw, content, ranges, contentType, size)
+				}(contentType)
+
+				length = -1
+				body.reader = pr
+				body.closer = &rangesCloser{pipe: pr, done: done, file: body.closer}
+				contentType = "multipart/byteranges; boundary=" + mw.Boundary()
+			}
+		}
+	}
+
+	out.SetHeader("Content-Type", contentType)
+	out.Status(status)
+	if req.Method() == http.MethodHead {
+		return
+	}
+
+	body.size = length
+	out.Body(body)
+
+	// body由底层框架负责关闭
+	body = nil
+}
+
+// MediaTypes 文件下载不参与协商
+func (r *FileResponsor) MediaTypes() []string {
+	return nil
+}
+
+// fileResponse converts the FileResponse to a Response rendered by [FileResponsor].
+func fileResponse(file *FileResponse) *Response {
+	return &Response{
+		Header: file.Header,
+		Render: File,
+		Body:   file,
+	}
+}
+
+func (f *FileResponse) open() (io.ReadSeeker, string, time.Time, error) {
+	if f.Path == "" {
+		return f.Content, f.Name, f.ModTime, nil
+	}
+
+	osfile, err := os.Open(f.Path)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	stat, err := osfile.Stat()
+	if err != nil {
+		_ = osfile.Close()
+		return nil, "", time.Time{}, err
+	}
+
+	if stat.IsDir() {
+		_ = osfile.Close()
+		return nil, "", time.Time{}, fmt.Errorf("%s is a directory: %w", f.Path, os.ErrNotExist)
+	}
+
+	name, modtime := f.Name, f.ModTime
+	if name == "" {
+		name = filepath.Base(f.Path)
+	}
+
+	if modtime.IsZero() {
+		modtime = stat.ModTime()
+	}
+
+	return osfile, name, modtime, nil
+}
+
+// contentType detects the content type by the extension of the name, then by the first 512 bytes of the content.
+func (f *FileResponse) contentType(content io.ReadSeeker, name string) (string, error) {
+	if f.ContentType != "" {
+		return f.ContentType, nil
+	}
+
+	if ctype := mime.TypeByExtension(filepath.Ext(name)); ctype != "" {
+		return ctype, nil
+	}
+
+	var buf [512]byte
+	n, _ := io.ReadFull(content, buf[:])
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// checkPreconditions evaluates If-None-Match and If-Modified-Since,
+// it returns the status to answer with and true if the body must not be sent.
+func checkPreconditions(req HttpRequest, etag string, modtime time.Time) (int, bool) {
+	get := req.Method() == http.MethodGet || req.Method() == http.MethodHead
+	if inm, ok := req.Header().Get("If-None-Match"); ok {
+		if !etagMatch(inm, etag, false) {
+			return 0, false
+		}
+
+		if get {
+			return http.StatusNotModified, true
+		}
+
+		return http.StatusPreconditionFailed, true
+	}
+
+	ims, ok := req.Header().Get("If-Modified-Since")
+	if !ok || !get || isZeroTime(modtime) {
+		return 0, false
+	}
+
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return 0, false
+	}
+
+	// Last-Modified只精确到秒
+	if !modtime.Truncate(time.Second).After(t) {
+		return http.StatusNotModified, true
+	}
+
+	return 0, false
+}
+
+// checkIfRange reports whether the Range header should be honored, by the strong comparison of If-Range.
+func checkIfRange(req HttpRequest, etag string, modtime time.Time) bool {
+	ir, ok := req.Header().Get("If-Range")
+	if !ok {
+		return true
+	}
+
+	if strings.HasPrefix(ir, `"`) || strings.HasPrefix(ir, "W/") {
+		return etagMatch(ir, etag, true)
+	}
+
+	t, err := http.ParseTime(ir)
+	return err == nil && !isZeroTime(modtime) && modtime.Truncate(time.Second).Equal(t)
+}
+
+// etagMatch reports whether the etag matches any one in the list, eg. `"a", W/"b"`.
+// A weak etag never matches in strong comparison.
+func etagMatch(list, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	if strings.TrimSpace(list) == "*" {
+		return !strong
+	}
+
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong {
+			if candidate == etag {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isZeroTime(t time.Time) bool {
+	return t.IsZero() || t.Equal(time.Unix(0, 0))
+}
+
+// httpRange is a byte range of the content.
+type httpRange struct {
+	start, length int64
+}
+
+func (r httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+var errNoOverlap = errors.New("invalid range: failed to overlap")
+
+// parseRange parses the Range header, eg. "bytes=0-99,-100".
+// Ranges beyond the content are dropped, errNoOverlap is returned if none of the ranges remains.
+func parseRange(s string, size int64) ([]httpRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(s, prefix) {
+		return nil, errors.New("invalid range")
+	}
+
+	var ranges []httpRange
+	noOverlap := false
+	for _, ra := range strings.Split(s[len(prefix):], ",") {
+		ra = strings.TrimSpace(ra)
+		if ra == "" {
+			continue
+		}
+
+		start, end, ok := strings.Cut(ra, "-")
+		if !ok {
+			return nil, errors.New("invalid range")
+		}
+
+		start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+		var r httpRange
+		if start == "" {
+			// 后缀范围，例如"-100"表示最后100个字节
+			i, err := strconv.ParseInt(end, 10, 64)
+			if end == "" || err != nil || i < 0 {
+				return nil, errors.New("invalid range")
+			}
+
+			if i == 0 {
+				noOverlap = true
+				continue
+			}
+
+			if i > size {
+				i = size
+			}
+
+			r.start = size - i
+			r.length = size - r.start
+		} else {
+			i, err := strconv.ParseInt(start, 10, 64)
+			if err != nil || i < 0 {
+				return nil, errors.New("invalid range")
+			}
+
+			if i >= size {
+				noOverlap = true
+				continue
+			}
+
+			r.start = i
+			if end == "" {
+				r.length = size - r.start
+			} else {
+				i, err := strconv.ParseInt(end, 10, 64)
+				if err != nil || r.start > i {
+					return nil, errors.New("invalid range")
+				}
+
+				if i >= size {
+					i = size - 1
+				}
+
+				r.length = i - r.start + 1
+			}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		if noOverlap {
+			return nil, errNoOverlap
+		}
+
+		return nil, errors.New("invalid range")
+	}
+
+	return ranges, nil
+}
+
+func sumRangesSize(ranges []httpRange) (size int64) {
+	for _, ra := range ranges {
+		size += ra.length
+	}
+
+	return
+}
+
+// writeRanges writes the ranges as multipart/byteranges.
+func writeRanges(mw *multipart.Writer, pw *io.PipeWriter, content io.ReadSeeker, ranges []httpRange, contentType string, size int64) {
+	for _, ra := range ranges {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Range": {ra.contentRange(size)},
+			"Content-Type":  {contentType},
+		})
+		if err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+
+		if _, err := content.Seek(ra.start, io.SeekStart); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+
+		if _, err := io.CopyN(part, content, ra.length); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+	}
+
+	_ = pw.CloseWithError(mw.Close())
+}
+
+// fileBody is the response body of the file, the file is closed by the underlying web framework after writing.
+type fileBody struct {
+	reader io.Reader
+	closer io.Closer
+
+	// size is the remaining bytes, negative if unknown.
+	size int64
+}
+
+func (b *fileBody) Read(p []byte) (int, error) {
+	n, err := b.reader.Read(p)
+	if b.size >= 0 {
+		b.size -= int64(n)
+	}
+
+	return n, err
+}
+
+// Len returns the remaining bytes, the adapter uses it as Content-Length.
+func (b *fileBody) Len() int {
+	return int(b.size)
+}
+
+func (b *fileBody) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+
+	return b.closer.Close()
+}
+
+func toCloser(content io.ReadSeeker) io.Closer {
+	closer, _ := content.(io.Closer)
+	return closer
+}
+
+// rangesCloser closes the multipart/byteranges body. The pipe is closed first so that writeRanges stops,
+// the file is closed only after writeRanges returns, it may still be reading the file, eg. the client is gone.
+type rangesCloser struct {
+	pipe *io.PipeReader
+	done <-chan struct{}
+	file io.Closer
+}
+
+func (c *rangesCloser) Close() error {
+	err := c.pipe.Close()
+	<-c.done
+
+	if c.file == nil {
+		return err
+	}
+
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
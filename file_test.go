@@ -0,0 +1,221 @@
+package gmvc
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var fileModTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+type fileAction struct {
+	Path string `param:"path,Query"`
+}
+
+func (a *fileAction) Go() (interface{}, error) {
+	if a.Path != "" {
+		return &FileResponse{Path: a.Path}, nil
+	}
+
+	return &FileResponse{
+		Content: strings.NewReader("0123456789"),
+		Name:    "数字.txt",
+		ModTime: fileModTime,
+		ETag:    `"v1"`,
+	}, nil
+}
+
+func serveFile(header map[string]string) *mockContext {
+	return serveFileMethod(http.MethodGet, "/file", header)
+}
+
+func serveFileMethod(method, target string, header map[string]string) *mockContext {
+	ctx := newMockContext(method, target)
+	for k, v := range header {
+		ctx.req.header.set(k, v)
+	}
+
+	serve(CreateGmvcBuilder(), &fileAction{}, ctx)
+	return ctx
+}
+
+func TestFileResponse(t *testing.T) {
+	ctx := serveFile(nil)
+
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, "0123456789", ctx.resp.body.String())
+	assert.Equal(t, []string{"text/plain; charset=utf-8"}, ctx.resp.header["Content-Type"])
+	assert.Equal(t, []string{"bytes"}, ctx.resp.header["Accept-Ranges"])
+	assert.Equal(t, []string{`"v1"`}, ctx.resp.header["Etag"])
+	assert.Equal(t, []string{"Tue, 02 Jan 2024 03:04:05 GMT"}, ctx.resp.header["Last-Modified"])
+
+	_, params, err := mime.ParseMediaType(ctx.resp.header["Content-Disposition"][0])
+	assert.Nil(t, err)
+	assert.Equal(t, "数字.txt", params["filename"])
+}
+
+func TestFileResponsePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.csv")
+	assert.Nil(t, os.WriteFile(path, []byte("a,b\n"), 0o644))
+
+	ctx := serveFileMethod(http.MethodGet, "/file?path="+path, nil)
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, "a,b\n", ctx.resp.body.String())
+	assert.Equal(t, []string{`attachment; filename=report.csv`}, ctx.resp.header["Content-Disposition"])
+
+	ctx = serveFileMethod(http.MethodGet, "/file?path="+filepath.Join(dir, "missing"), nil)
+	assert.Equal(t, http.StatusNotFound, ctx.resp.status)
+}
+
+func TestFileResponseConditional(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		header map[string]string
+		status int
+	}{
+		{name: "etag match", header: map[string]string{"If-None-Match": `"v0", W/"v1"`}, status: http.StatusNotModified},
+		{name: "etag mismatch", header: map[string]string{"If-None-Match": `"v0"`}, status: http.StatusOK},
+		{name: "etag precedes date", header: map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"}, status: http.StatusOK},
+		{name: "not modified", header: map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"}, status: http.StatusNotModified},
+		{name: "modified", header: map[string]string{"If-Modified-Since": "Mon, 01 Jan 2024 00:00:00 GMT"}, status: http.StatusOK},
+		{name: "precondition failed", method: http.MethodPost, header: map[string]string{"If-None-Match": "*"}, status: http.StatusPreconditionFailed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			method := c.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			ctx := serveFileMethod(method, "/file", c.header)
+			assert.Equal(t, c.status, ctx.resp.status)
+			if c.status != http.StatusOK {
+				assert.Equal(t, 0, ctx.resp.body.Len())
+			}
+		})
+	}
+}
+
+func TestFileResponseRange(t *testing.T) {
+	cases := []struct {
+		name         string
+		header       map[string]string
+		status       int
+		contentRange string
+		body         string
+	}{
+		{name: "first bytes", header: map[string]string{"Range": "bytes=0-3"}, status: http.StatusPartialContent, contentRange: "bytes 0-3/10", body: "0123"},
+		{name: "open end", header: map[string]string{"Range": "bytes=7-"}, status: http.StatusPartialContent, contentRange: "bytes 7-9/10", body: "789"},
+		{name: "suffix", header: map[string]string{"Range": "bytes=-2"}, status: http.StatusPartialContent, contentRange: "bytes 8-9/10", body: "89"},
+		{name: "end beyond size", header: map[string]string{"Range": "bytes=8-100"}, status: http.StatusPartialContent, contentRange: "bytes 8-9/10", body: "89"},
+		{name: "unsatisfiable", header: map[string]string{"Range": "bytes=10-"}, status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */10"},
+		{name: "malformed", header: map[string]string{"Range": "bytes=5-1"}, status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */10"},
+		{name: "if-range match", header: map[string]string{"Range": "bytes=0-0", "If-Range": `"v1"`}, status: http.StatusPartialContent, contentRange: "bytes 0-0/10", body: "0"},
+		{name: "if-range mismatch", header: map[string]string{"Range": "bytes=0-0", "If-Range": `"v0"`}, status: http.StatusOK, body: "0123456789"},
+		{name: "overlong ranges", header: map[string]string{"Range": "bytes=0-9,0-9"}, status: http.StatusOK, body: "0123456789"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := serveFile(c.header)
+			assert.Equal(t, c.status, ctx.resp.status)
+			assert.Equal(t, c.body, ctx.resp.body.String())
+
+			contentRange, _ := ctx.resp.header.Get("Content-Range")
+			assert.Equal(t, c.contentRange, contentRange)
+		})
+	}
+}
+
+func TestFileResponseMultiRange(t *testing.T) {
+	ctx := serveFile(map[string]string{"Range": "bytes=0-1,5-6"})
+	assert.Equal(t, http.StatusPartialContent, ctx.resp.status)
+
+	contentType, _ := ctx.resp.header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/byteranges", mediaType)
+
+	reader := multipart.NewReader(&ctx.resp.body, params["boundary"])
+	for _, want := range []struct{ contentRange, body string }{
+		{contentRange: "bytes 0-1/10", body: "01"},
+		{contentRange: "bytes 5-6/10", body: "56"},
+	} {
+		part, err := reader.NextPart()
+		assert.Nil(t, err)
+		assert.Equal(t, want.contentRange, part.Header.Get("Content-Range"))
+		assert.Equal(t, "text/plain; charset=utf-8", part.Header.Get("Content-Type"))
+
+		b, _ := io.ReadAll(part)
+		assert.Equal(t, want.body, string(b))
+	}
+
+	_, err = reader.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+// slowFile reads slowly, and records whether it's read after being closed.
+type slowFile struct {
+	*strings.Reader
+
+	mu              sync.Mutex
+	closed          bool
+	readAfterClosed bool
+}
+
+func (f *slowFile) Read(p []byte) (int, error) {
+	time.Sleep(20 * time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		f.readAfterClosed = true
+	}
+
+	return f.Reader.Read(p)
+}
+
+func (f *slowFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// disconnectedResponse reads the first chunk of the body, then the client goes away.
+type disconnectedResponse struct {
+	HttpResponse
+}
+
+func (r *disconnectedResponse) Body(in io.Reader) {
+	_, _ = in.Read(make([]byte, 4096))
+	_ = in.(io.Closer).Close()
+}
+
+func TestFileResponseMultiRangeDisconnected(t *testing.T) {
+	file := &slowFile{Reader: strings.NewReader("0123456789")}
+
+	ctx := newMockContext(http.MethodGet, "/file")
+	ctx.req.header.set("Range", "bytes=0-1,5-6")
+	out := &disconnectedResponse{HttpResponse: ctx.HttpResponse()}
+	(&FileResponsor{}).Response(withResponse(ctx, out), fileResponse(&FileResponse{Content: file, Name: "a.txt"}))
+
+	// the file is closed after the ranges stop reading it, a read still running would be done by now
+	time.Sleep(50 * time.Millisecond)
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	assert.True(t, file.closed)
+	assert.False(t, file.readAfterClosed)
+}
@@ -0,0 +1,241 @@
+package gmvc
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// RenderFilter is implemented by middlewares which inspect or rewrite the rendered response, eg. ETag and compression.
+// FilterRender is called with the result of the Action (or of the error handler) before it is rendered,
+// render writes the result to out, which can be ctx.HttpResponse() or a wrapper of it, eg. a [BufferedResponse].
+// The filter is skipped if [IMiddleware.IsApply] returns false, filters run in the order of the middlewares.
+type RenderFilter interface {
+	FilterRender(ctx GmvcContext, result interface{}, render func(out HttpResponse))
+}
+
+// renderContext overrides the HttpResponse of the GmvcContext for the filters and the Responsor.
+type renderContext struct {
+	GmvcContext
+	out HttpResponse
+}
+
+func (c *renderContext) HttpResponse() HttpResponse {
+	return c.out
+}
+
+func withResponse(ctx GmvcContext, out HttpResponse) GmvcContext {
+	if ctx.HttpResponse() == out {
+		return ctx
+	}
+
+	if c, ok := ctx.(*renderContext); ok {
+		return &renderContext{GmvcContext: c.GmvcContext, out: out}
+	}
+
+	return &renderContext{GmvcContext: ctx, out: out}
+}
+
+// render renders the result through the filters.
+func (gmvc *GmvcBuilder) render(ctx GmvcContext, result interface{}, filters []RenderFilter) {
+	// response已经被写出时不再经过filter
+	if len(filters) == 0 || ctx.HttpResponse().Committed() {
+		gmvc.doResponse(ctx, result)
+		return
+	}
+
+	render := func(out HttpResponse) {
+		gmvc.doResponse(withResponse(ctx, out), result)
+	}
+
+	for i := len(filters) - 1; i >= 0; i-- {
+		filter, next := filters[i], render
+		render = func(out HttpResponse) {
+			c := withResponse(ctx, out)
+			if midware, ok := filter.(IMiddleware); ok && !midware.IsApply(c) {
+				next(out)
+				return
+			}
+
+			filter.FilterRender(c, result, next)
+		}
+	}
+
+	render(ctx.HttpResponse())
+}
+
+var _ HttpResponse = (*BufferedResponse)(nil)
+
+// BufferedResponse captures the rendered response in memory for [RenderFilter],
+// it is written to the underlying HttpResponse by [BufferedResponse.WriteTo].
+// HTML rendered by the underlying web framework can't be captured, it is forwarded to the underlying HttpResponse.
+type BufferedResponse struct {
+	out     HttpResponse
+	status  int
+	header  bufferedHeader
+	cookies []*http.Cookie
+	body    bytes.Buffer
+
+	committed bool
+	forwarded bool
+}
+
+// NewBufferedResponse creates a BufferedResponse over out.
+func NewBufferedResponse(out HttpResponse) *BufferedResponse {
+	return &BufferedResponse{
+		out:    out,
+		status: http.StatusOK,
+		header: bufferedHeader{},
+	}
+}
+
+// HTML is forwarded to the underlying HttpResponse.
+func (b *BufferedResponse) HTML(status int, body string, model any) {
+	b.copyHeader()
+	b.forwarded = true
+	b.out.HTML(status, body, model)
+}
+
+// Status sets the HTTP response status code.
+func (b *BufferedResponse) Status(code int) { b.status = code }
+
+// Header returns the response header.
+func (b *BufferedResponse) Header() Header { return b.header }
+
+// SetHeader sets the HTTP response header, an empty value removes the header.
+func (b *BufferedResponse) SetHeader(key, value string) {
+	if value == "" {
+		http.Header(b.header).Del(key)
+		return
+	}
+
+	http.Header(b.header).Set(key, value)
+}
+
+// SetCookie buffers the cookie.
+func (b *BufferedResponse) SetCookie(cookie *http.Cookie) { b.cookies = append(b.cookies, cookie) }
+
+// Body reads in into the buffer.
+func (b *BufferedResponse) Body(in io.Reader) {
+	b.committed = true
+	b.body.Reset()
+	_, _ = io.Copy(&b.body, in)
+	if closer, ok := in.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// StatusCode returns the HTTP response status code.
+func (b *BufferedResponse) StatusCode() int { return b.status }
+
+// Committed reports whether the body has been written.
+func (b *BufferedResponse) Committed() bool { return b.committed || b.forwarded }
+
+// Written returns the number of bytes buffered.
+func (b *BufferedResponse) Written() int64 { return int64(b.body.Len()) }
+
+// Writer returns a writer appending to the buffer, Flush does nothing.
+func (b *BufferedResponse) Writer() ResponseWriter {
+	b.committed = true
+	return (*bufferedWriter)(b)
+}
+
+// Bytes returns the buffered body.
+func (b *BufferedResponse) Bytes() []byte { return b.body.Bytes() }
+
+// Forwarded reports whether the response has been forwarded to the underlying HttpResponse by HTML,
+// nothing is buffered then.
+func (b *BufferedResponse) Forwarded() bool { return b.forwarded }
+
+// WriteTo writes the buffered status, header and body to the underlying HttpResponse.
+func (b *BufferedResponse) WriteTo(out HttpResponse) {
+	if b.forwarded {
+		return
+	}
+
+	b.WriteHeaderTo(out)
+	if b.committed {
+		out.Body(bytes.NewReader(b.body.Bytes()))
+	}
+}
+
+// WriteHeaderTo writes the buffered status and header to the underlying HttpResponse, without the body.
+func (b *BufferedResponse) WriteHeaderTo(out HttpResponse) {
+	if b.forwarded {
+		return
+	}
+
+	b.header.writeTo(out)
+	for _, cookie := range b.cookies {
+		out.SetCookie(cookie)
+	}
+
+	out.Status(b.status)
+}
+
+func (b *BufferedResponse) copyHeader() {
+	b.header.writeTo(b.out)
+	for _, cookie := range b.cookies {
+		b.out.SetCookie(cookie)
+	}
+}
+
+type bufferedWriter BufferedResponse
+
+func (w *bufferedWriter) Write(p []byte) (int, error) { return w.body.Write(p) }
+func (w *bufferedWriter) Flush() error                { return nil }
+
+var _ Header = (bufferedHeader)(nil)
+
+type bufferedHeader http.Header
+
+// Get implements Header.
+func (h bufferedHeader) Get(key string) (string, bool) {
+	values, ok := h.Gets(key)
+	if !ok {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// Gets implements Header.
+func (h bufferedHeader) Gets(key string) ([]string, bool) {
+	values, ok := h[http.CanonicalHeaderKey(key)]
+	if !ok || len(values) == 0 {
+		return nil, false
+	}
+
+	return values, true
+}
+
+// VisitAll implements Header.
+func (h bufferedHeader) VisitAll(f func(k, v []byte)) {
+	for k, values := range h {
+		for _, v := range values {
+			f([]byte(k), []byte(v))
+		}
+	}
+}
+
+// writeTo writes every value of the header, Set-Cookie values are written one by one through SetCookie,
+// values of the other headers are combined by ", ", eg. Vary and Link.
+func (h bufferedHeader) writeTo(out HttpResponse) {
+	for k, values := range h {
+		if len(values) == 0 {
+			continue
+		}
+
+		if k == "Set-Cookie" {
+			resp := http.Response{Header: http.Header{k: values}}
+			for _, cookie := range resp.Cookies() {
+				out.SetCookie(cookie)
+			}
+
+			continue
+		}
+
+		out.SetHeader(k, strings.Join(values, ", "))
+	}
+}
@@ -2,10 +2,15 @@ package gmvc
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
-	"unicode"
+
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 var (
@@ -16,16 +21,19 @@ var (
 func CreateGmvcBuilder(options ...GmvcOption) *GmvcBuilder {
 	builder := &GmvcBuilder{
 		actions:       make(map[string]HandlerFunc),
-		checkerMap:    make(map[string]Checker),
+		checkerMap:    map[string]Checker{XRequired: RequiredChecker, XNotEmpty: NotEmptyChecker},
 		resolverMap:   make(map[string]Resolver),
 		typedResolver: make(map[reflect.Type]Resolver),
 		responsor:     make(map[RenderType]Responsor),
+		bodyDecoders:  make(map[string]BodyDecoder),
 		globalMidware: make([]IMiddleware, 0),
-		errHandler: func(ctx GmvcContext, err error) interface{} {
-			return err.Error()
-		},
+		errHandler:    DefaultErrorHandler,
 		options: GmvcOptions{
 			autodef: AnySrc, // 默认为任意位置
+			nested: nestedOptions{
+				depth:    defaultNestedDepth,
+				elements: defaultNestedElements,
+			},
 		},
 		singletons: &singletonContext{
 			typemap: make(map[reflect.Type]*singleton),
@@ -34,13 +42,35 @@ func CreateGmvcBuilder(options ...GmvcOption) *GmvcBuilder {
 	}
 
 	for _, option := range options {
-		option(builder.options)
+		option(&builder.options)
 	}
 
 	// Register default response render
-	builder.RegisterResponsor(JSON, &JSONResponsor{})
+	builder.RegisterResponsor(JSON, &JSONResponsor{
+		Prefix:            builder.options.json.prefix,
+		Indent:            builder.options.json.indent,
+		DisableHTMLEscape: builder.options.json.disableHTMLEscape,
+		Marshal:           builder.options.json.marshal,
+	})
 	builder.RegisterResponsor(HTML, &HTMLResponsor{})
 	builder.RegisterResponsor(String, &StringResponsor{})
+	builder.RegisterResponsor(Problem, &ProblemResponsor{})
+	builder.RegisterResponsor(XML, &XMLResponsor{})
+	builder.RegisterResponsor(MsgPack, &MsgPackResponsor{})
+	builder.RegisterResponsor(Protobuf, &ProtobufResponsor{})
+	builder.RegisterResponsor(Streaming, &StreamResponsor{})
+	builder.RegisterResponsor(SSE, &SSEResponsor{})
+	builder.RegisterResponsor(File, &FileResponsor{})
+
+	if builder.options.compress.enabled {
+		builder.compressor = newCompressFilter(builder.options.compress, builder.doRenderError)
+	}
+
+	// Register default body decoder
+	builder.RegisterBodyDecoder(json.Unmarshal, "application/json")
+	builder.RegisterBodyDecoder(xml.Unmarshal, "application/xml", "text/xml")
+	builder.RegisterBodyDecoder(msgpack.Unmarshal, "application/msgpack", "application/x-msgpack")
+	builder.RegisterBodyDecoder(decodeProtobuf, "application/x-protobuf", "application/protobuf")
 
 	// Register default resolver
 	builder.RegisterResolver("Json", func(ctx GmvcContext, fieldMeta *ParamMeta, origin string) (interface{}, error) {
@@ -71,9 +101,13 @@ type GmvcBuilder struct {
 	resolverMap   map[string]Resolver
 	typedResolver map[reflect.Type]Resolver
 	responsor     map[RenderType]Responsor
+	bodyDecoders  map[string]BodyDecoder
 	errHandler    HandleError
 	recover       RecoverFunc
 
+	// 开启压缩时，作为最外层的RenderFilter
+	compressor *compressFilter
+
 	// 注册进gmvc的全局Middleware
 	// 先注册先执行
 	globalMidware []IMiddleware
@@ -117,6 +151,9 @@ func (gmvc *GmvcBuilder) RegisterSingleton(name string, obj interface{}) *GmvcBu
 
 // RegisterSingleton 注册需要组装到action中的实例
 func (gmvc *GmvcBuilder) registerSingleton(name string, obj interface{}, omitdup bool) *GmvcBuilder {
+	// 绑定Config来源的字段
+	gmvc.configure(obj)
+
 	typ := reflect.TypeOf(obj)
 	if len(name) == 0 {
 		name = typ.Name()
@@ -145,6 +182,15 @@ func (gmvc *GmvcBuilder) RegisterResponsor(render RenderType, r Responsor) *Gmvc
 	return gmvc
 }
 
+// RegisterBodyDecoder 注册Body解析器，param:"Body"的非string、[]byte字段，按请求的Content-Type解析
+func (gmvc *GmvcBuilder) RegisterBodyDecoder(d BodyDecoder, mediaTypes ...string) *GmvcBuilder {
+	for _, mediaType := range mediaTypes {
+		gmvc.bodyDecoders[mediaType] = d
+	}
+
+	return gmvc
+}
+
 // RegisterResponsor 注册返回器
 func (gmvc *GmvcBuilder) AddMiddleware(midware IMiddleware) *GmvcBuilder {
 	gmvc.globalMidware = append(gmvc.globalMidware, midware)
@@ -203,13 +249,19 @@ func (gmvc *GmvcBuilder) BuildAction(action Action, midware ...IMiddleware) Hand
 			return nil, err
 		}
 
-		// 2. 调用Init方法
+		// 2. 检查Action声明的角色和权限
+		err = authorize(ctx, handlerInstance)
+		if err != nil {
+			return nil, err
+		}
+
+		// 3. 调用Init方法
 		err = gmvc.initialize(ctx, handlerInstance)
 		if err != nil {
 			return nil, err
 		}
 
-		// 3. 调用Go方法
+		// 4. 调用Go方法
 		resp, err := gmvc.launch(ctx, handlerInstance)
 		if err != nil {
 			return nil, err
@@ -250,35 +302,33 @@ func (gmvc *GmvcBuilder) BuildAction(action Action, midware ...IMiddleware) Hand
 		next = next0
 	}
 
+	// 实现了RenderFilter的middleware同时作用于渲染
+	filters := make([]RenderFilter, 0)
+	if gmvc.compressor != nil {
+		filters = append(filters, gmvc.compressor)
+	}
+
+	for _, midware := range midwares {
+		if filter, ok := midware.(RenderFilter); ok {
+			filters = append(filters, filter)
+		}
+	}
+
 	// the outer handlerfunc
 	handlerfunc := func(ctx GmvcContext) {
 		defer func() {
 			if x := recover(); x != nil {
-				if gmvc.recover != nil {
-					ret, err := gmvc.recover(ctx, x)
-					if err != nil {
-						// TODO: check if has responsed.
-						resp := gmvc.errHandler(ctx, err)
-						gmvc.doResponse(ctx, resp)
-						return
-					}
-
-					gmvc.doResponse(ctx, ret)
-				} else {
-					ctx.HttpResponse().Status(http.StatusInternalServerError)
-				}
+				gmvc.recovery(ctx, x)
 			}
 		}()
 
 		ctx.SetActionMeta(actionMeta)
 		resp, err := next(ctx)
 		if err != nil {
-			resp := gmvc.errHandler(ctx, err)
-			gmvc.doResponse(ctx, resp)
-			return
+			resp = gmvc.handleError(ctx, err)
 		}
 
-		gmvc.doResponse(ctx, resp)
+		gmvc.render(ctx, resp, filters)
 	}
 
 	return handlerfunc
@@ -306,6 +356,15 @@ func (instance *GmvcBuilder) launch(ctx GmvcContext, handler interface{}) (inter
 }
 
 func (gmvc *GmvcBuilder) doResponse(ctx GmvcContext, resp interface{}) {
+	// response has been written by user through the underlying web framework, do not clobber it.
+	if ctx.HttpResponse().Committed() {
+		if resp != nil {
+			logInfo(ctx, "gmvc: action %s returns a result after the response is committed, result dropped", actionName(ctx))
+		}
+
+		return
+	}
+
 	// if resp is nil, means gmvc no need do response for user
 	if resp == nil {
 		// return 204 No Content
@@ -314,8 +373,12 @@ func (gmvc *GmvcBuilder) doResponse(ctx GmvcContext, resp interface{}) {
 	}
 
 	if entity, ok := resp.(Response); ok {
+		resp = &entity
+	}
+
+	if entity, ok := resp.(*Response); ok {
 		if responsor, ok := gmvc.responsor[entity.Render]; ok {
-			responsor.Response(ctx, &entity)
+			gmvc.respond(ctx, responsor, gmvc.envelopeSuccess(ctx, entity))
 		} else {
 			ctx.HttpResponse().Status(http.StatusInternalServerError)
 		}
@@ -323,24 +386,112 @@ func (gmvc *GmvcBuilder) doResponse(ctx GmvcContext, resp interface{}) {
 		return
 	}
 
-	if entity, ok := resp.(*Response); ok {
-		if responsor, ok := gmvc.responsor[entity.Render]; ok {
-			responsor.Response(ctx, entity)
-		} else {
-			ctx.HttpResponse().Status(http.StatusInternalServerError)
+	// Stream、SSE和文件不参与内容协商
+	switch entity := resp.(type) {
+	case *Stream:
+		gmvc.responsor[Streaming].Response(ctx, streamResponse(entity))
+		return
+	case *SSEResponse:
+		gmvc.responsor[SSE].Response(ctx, sseResponse(entity))
+		return
+	case *FileResponse:
+		gmvc.responsor[File].Response(ctx, fileResponse(entity))
+		return
+	}
+
+	// 默认使用JSON进行返回，开启内容协商时根据Accept选择
+	render := JSON
+	if candidates := gmvc.candidates(ctx); len(candidates) > 0 {
+		addVary(ctx.HttpResponse(), "Accept")
+
+		accept, _ := ctx.HttpRequest().Header().Get("Accept")
+		chosen, ok := gmvc.negotiate(accept, candidates, resp)
+		if !ok {
+			gmvc.doNotAcceptable(ctx, candidates)
+			return
 		}
 
-		return
+		render = chosen
 	}
 
-	// 默认使用JSON进行返回
 	gmvc.doResponse(ctx, &Response{
-		Render:     JSON,
+		Render:     render,
 		Body:       resp,
 		StatusCode: http.StatusOK,
 	})
 }
 
+// respond renders the response, the encoding error of a fallibleResponsor is answered by the error handler.
+func (gmvc *GmvcBuilder) respond(ctx GmvcContext, responsor Responsor, resp *Response) {
+	fallible, ok := responsor.(fallibleResponsor)
+	if !ok {
+		responsor.Response(ctx, resp)
+		return
+	}
+
+	if err := fallible.render(ctx, resp); err != nil {
+		gmvc.doRenderError(ctx, err)
+	}
+}
+
+// doRenderError responds the encoding error through the error handler, a bare 500 is answered if the error
+// response can't be rendered either.
+func (gmvc *GmvcBuilder) doRenderError(ctx GmvcContext, err error) {
+	resp := gmvc.handleError(ctx, err)
+	if resp == nil {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	if entity, ok := resp.(Response); ok {
+		resp = &entity
+	}
+
+	entity, ok := resp.(*Response)
+	if !ok {
+		entity = &Response{Render: JSON, Body: resp, StatusCode: AsHTTPError(err).Status}
+	}
+
+	responsor, ok := gmvc.responsor[entity.Render]
+	if !ok {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	entity = gmvc.envelopeSuccess(ctx, entity)
+	if fallible, ok := responsor.(fallibleResponsor); ok {
+		if fallible.render(ctx, entity) != nil {
+			ctx.HttpResponse().Status(http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	responsor.Response(ctx, entity)
+}
+
+// doNotAcceptable responds 406 through the error handler, the error response is never negotiated again.
+func (gmvc *GmvcBuilder) doNotAcceptable(ctx GmvcContext, candidates []RenderType) {
+	available := make([]string, 0, len(candidates))
+	for _, render := range candidates {
+		if responsor, ok := gmvc.responsor[render]; ok {
+			available = append(available, responsor.MediaTypes()...)
+		}
+	}
+
+	resp := gmvc.handleError(ctx, NotAcceptable("").WithDetails(available))
+	switch resp.(type) {
+	case Response, *Response, nil:
+		gmvc.doResponse(ctx, resp)
+	default:
+		gmvc.doResponse(ctx, &Response{
+			Render:     JSON,
+			Body:       resp,
+			StatusCode: http.StatusNotAcceptable,
+		})
+	}
+}
+
 func (gmvc *GmvcBuilder) resolve(c GmvcContext, meta *ActionMeta) (interface{}, error) {
 	// 实例化handler
 	handlerValuePtr := reflect.New(meta.handlerType)
@@ -353,26 +504,73 @@ func (gmvc *GmvcBuilder) resolve(c GmvcContext, meta *ActionMeta) (interface{},
 	c.SetAction(handlerValuePtr.Interface() /* the instance pointer of the Action */)
 
 	// check every params
-	if err := gmvc.checkFieldValue(c, meta); err != nil {
+	if err := gmvc.checkFieldValue(c, handlerValuePtr, meta); err != nil {
 		return nil, err
 	}
 
+	// 跨字段的整体校验，在Init之前
+	if validator, ok := handlerValuePtr.Interface().(Validator); ok {
+		if err := validator.Validate(c); err != nil {
+			return nil, asValidationError(err)
+		}
+	}
+
 	return handlerValuePtr.Interface(), nil
 }
 
-func (gmvc *GmvcBuilder) checkFieldValue(ctx GmvcContext, meta *ActionMeta) error {
+func (gmvc *GmvcBuilder) checkFieldValue(ctx GmvcContext, pvalue reflect.Value, meta *ActionMeta) error {
+	failures, err := gmvc.checkStruct(ctx, pvalue.Elem(), meta)
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return &ValidationError{Fields: failures}
+	}
+
+	return nil
+}
+
+// checkStruct 检查结构体每个字段的值，包括Recursive的结构体，HTTPError直接返回
+func (gmvc *GmvcBuilder) checkStruct(ctx GmvcContext, value reflect.Value, meta *ActionMeta) ([]*FieldError, error) {
+	var failures []*FieldError
 	for i := 0; i < meta.fieldNum; i++ {
 		fieldMeta := meta.fieldList[i]
 
-		if err := gmvc.validateValue(ctx, fieldMeta.value, fieldMeta, meta); err != nil {
-			return err
+		// Checker检查的是绑定之后字段的值，每个请求独立
+		field := value.Field(i)
+		if !field.CanInterface() {
+			continue
+		}
+
+		if fieldMeta.isRecursive && fieldMeta.handlerMeta != nil {
+			if recursive, ok := unwrapValue(field); ok && recursive.Kind() == reflect.Struct {
+				fieldErrs, err := gmvc.checkStruct(ctx, recursive, fieldMeta.handlerMeta)
+				if err != nil {
+					return nil, err
+				}
+
+				failures = append(failures, fieldErrs...)
+			}
+		}
+
+		for _, fieldErr := range gmvc.validateField(ctx, fieldMeta, field, value) {
+			var httpErr *HTTPError
+			if errors.As(fieldErr.Err, &httpErr) {
+				return nil, fieldErr.Err
+			}
+
+			failures = append(failures, fieldErr)
 		}
 	}
 
-	return nil
+	return failures, nil
 }
 
 func (gmvc *GmvcBuilder) resolveFieldValue(ctx GmvcContext, pvalue reflect.Value, meta *ActionMeta) error {
+	// 类型转换失败的参数，全部收集后一起返回
+	var failures []*FieldError
+
 	for i := 0; i < meta.fieldNum; i++ {
 		fieldMeta := meta.fieldList[i]
 
@@ -388,8 +586,13 @@ func (gmvc *GmvcBuilder) resolveFieldValue(ctx GmvcContext, pvalue reflect.Value
 
 		if fieldMeta.isRecursive {
 			recursiveValueStr := reflect.New(fieldMeta.handlerMeta.handlerType)
-			if resp := gmvc.resolveFieldValue(ctx, recursiveValueStr, fieldMeta.handlerMeta); resp != nil {
-				return resp
+			if err := gmvc.resolveFieldValue(ctx, recursiveValueStr, fieldMeta.handlerMeta); err != nil {
+				var bindingErr *BindingError
+				if !errors.As(err, &bindingErr) {
+					return err
+				}
+
+				failures = append(failures, bindingErr.Fields...)
 			}
 			pvalue.Elem().Field(i).Set(recursiveValueStr.Elem())
 		}
@@ -411,16 +614,33 @@ func (gmvc *GmvcBuilder) resolveFieldValue(ctx GmvcContext, pvalue reflect.Value
 			  - if param's type match one of the registered typed-resolver, then use typed-resolver to resolve the value.
 			  - auto type-convert.
 			*/
+			if ok && gmvc.options.rejectAmbiguous {
+				if fieldErr := gmvc.checkAmbiguous(ctx, fieldMeta, src, originValue); fieldErr != nil {
+					failures = append(failures, fieldErr)
+					continue
+				}
+			}
+
 			if ok {
 				ctx.Report(fieldMeta.fieldName)
 
-				if src == CtxSrc {
+				// default值不算作请求中出现的参数
+				if src != DefaultSrc {
+					ctx.MarkPresent(fieldMeta.path)
+				}
+
+				if src == CtxSrc || src == PrincipalSrc {
 					value = originValue
 				} else if fieldMeta.resolver != nil {
 					if tmp, ok := originValue.([]byte); ok {
 						originValue = string(tmp)
 					}
 
+					// Resolver只处理第一个值
+					if tmp, ok := originValue.([]string); ok {
+						originValue = tmp[0]
+					}
+
 					var err error
 					if value, err = fieldMeta.resolver(ctx, fieldMeta, originValue.(string)); err != nil {
 						return err
@@ -430,26 +650,59 @@ func (gmvc *GmvcBuilder) resolveFieldValue(ctx GmvcContext, pvalue reflect.Value
 						originValue = string(tmp)
 					}
 
+					if tmp, ok := originValue.([]string); ok {
+						originValue = tmp[0]
+					}
+
 					var err error
 					if value, err = resolver(ctx, fieldMeta, originValue.(string)); err != nil {
 						return err
 					}
 				} else if src == BodySrc {
-					// 处理body, 只有field为string或者[]byte时，才能进行自动赋值
-					if fieldMeta.fieldType.Type.Kind() == reflect.String {
-						value = string(originValue.([]byte))
-					} else {
-						value = originValue // must be []byte
+					// 处理body, 根据Content-Type解析
+					var err error
+					if value, err = gmvc.decodeBody(ctx, fieldMeta, originValue.([]byte)); err != nil {
+						var fieldErr *FieldError
+						if !errors.As(err, &fieldErr) {
+							return err
+						}
+
+						failures = append(failures, fieldErr)
+					}
+				} else if values, ok := originValue.(prefixedValues); ok {
+					var err error
+					if value, err = convertPrefixed(fieldMeta, values); err != nil {
+						var fieldErr *FieldError
+						if !errors.As(err, &fieldErr) {
+							return err
+						}
+
+						failures = append(failures, fieldErr)
+					}
+				} else if values, ok := originValue.(nestedValues); ok {
+					var err error
+					if value, err = gmvc.decodeNested(fieldMeta, values); err != nil {
+						var fieldErr *FieldError
+						if !errors.As(err, &fieldErr) {
+							return err
+						}
+
+						failures = append(failures, fieldErr)
+					}
+				} else if values, ok := originValue.([]string); ok {
+					var err error
+					if value, err = convertValues(fieldMeta, values); err != nil {
+						failures = append(failures, &FieldError{Field: fieldMeta.fieldName, Value: strings.Join(values, "&"), Err: err})
 					}
 				} else {
-					value = gmvc.convertFieldValue(ctx, fieldMeta, originValue.(string), meta.handlerName)
+					var err error
+					if value, err = gmvc.convertFieldValue(ctx, fieldMeta, originValue.(string), meta.handlerName); err != nil {
+						failures = append(failures, &FieldError{Field: fieldMeta.fieldName, Value: originValue.(string), Err: err})
+					}
 				}
 			}
 		}
 
-		// set resolved value
-		fieldMeta.value = value
-
 		if value == nil {
 			continue
 		}
@@ -460,6 +713,12 @@ func (gmvc *GmvcBuilder) resolveFieldValue(ctx GmvcContext, pvalue reflect.Value
 			continue
 		}
 
+		// 自定义类型，例如time.Duration，直接赋值
+		if valueType.PkgPath() != "" || valueType != fieldMeta.fieldType.Type {
+			pvalue.Elem().Field(i).Set(reflect.ValueOf(value))
+			continue
+		}
+
 		// TODO: really need test all type? or just use Set method
 		switch valueType.Kind() {
 		case reflect.Int:
@@ -493,81 +752,182 @@ func (gmvc *GmvcBuilder) resolveFieldValue(ctx GmvcContext, pvalue reflect.Value
 		}
 	}
 
+	if len(failures) > 0 {
+		return &BindingError{Fields: failures}
+	}
+
 	return nil
 }
 
 func (instance *GmvcBuilder) drawOutOriginValue(ctx GmvcContext, fieldMeta *ParamMeta) (originValue interface{}, src Src, present bool) {
-	req := ctx.HttpRequest()
+	if fieldMeta.prefix != "" {
+		return drawOutPrefixed(ctx.HttpRequest(), fieldMeta)
+	}
 
-	if hasSourceTag(fieldMeta.source, HeaderSrc) {
-		src = HeaderSrc
-		originValue, present = req.Header().Get(fieldMeta.fieldName)
-		if present {
+	for _, src = range fieldMeta.order {
+		// body整体解析，不再查找后面的来源
+		if originValue, present = instance.drawOutSource(ctx, fieldMeta, src); present || src == BodySrc {
 			return
 		}
 	}
 
-	if hasSourceTag(fieldMeta.source, QuerySrc) {
-		src = QuerySrc
-		originValue, present = req.GetQuery(fieldMeta.fieldName)
-		if present {
-			return
-		}
+	if fieldMeta.hasDefault {
+		src = DefaultSrc
+		present = true
+		originValue = fieldMeta.def
 	}
 
-	if hasSourceTag(fieldMeta.source, PathSrc) {
-		src = PathSrc
-		originValue, present = req.GetPathParam(fieldMeta.fieldName)
-		if present {
-			return
+	return
+}
+
+// drawOutSource 从指定的来源查找参数，依次尝试参数名称以及别名
+func (instance *GmvcBuilder) drawOutSource(ctx GmvcContext, fieldMeta *ParamMeta, src Src) (interface{}, bool) {
+	switch src {
+	case BodySrc:
+		v := ctx.HttpRequest().Body()
+		return v, len(v) > 0
+	case ConfigSrc:
+		return instance.lookupConfig(fieldMeta)
+	case PrincipalSrc:
+		principal := GetPrincipal(ctx)
+		if principal == nil {
+			return nil, false
 		}
-	}
 
-	if hasSourceTag(fieldMeta.source, FormSrc) {
-		src = FormSrc
-		originValue, present = req.GetForm(fieldMeta.fieldName)
-		if present {
-			return
+		if fieldMeta.fieldType.Type.Kind() == reflect.Struct {
+			return *principal, true
 		}
+
+		return principal, true
 	}
 
-	if hasSourceTag(fieldMeta.source, BodySrc) {
-		src = BodySrc
-		v := req.Body()
-		present = len(v) > 0
-		originValue = v
-		return
+	names := fieldMeta.names
+
+	// 命名策略不作用于Header和Ctx，仍然使用字段名称
+	if fieldMeta.derived && (src == HeaderSrc || src == CtxSrc) {
+		names = []string{fieldMeta.fieldType.Name}
 	}
 
-	if hasSourceTag(fieldMeta.source, CtxSrc) {
-		src = CtxSrc
-		originValue, present = ctx.GetCtx(fieldMeta.fieldName)
-		if present {
-			return
+	for _, name := range names {
+		if value, ok := instance.drawOutNamed(ctx, fieldMeta, src, name); ok {
+			return value, true
 		}
 	}
 
-	if fieldMeta.hasDefault {
-		src = DefaultSrc
-		present = true
-		originValue = fieldMeta.def
+	return nil, false
+}
+
+func (instance *GmvcBuilder) drawOutNamed(ctx GmvcContext, fieldMeta *ParamMeta, src Src, name string) (interface{}, bool) {
+	value, ok := instance.drawOutExact(ctx, fieldMeta, src, name)
+	if ok || !instance.options.foldCase {
+		return value, ok
 	}
 
-	return
+	// 忽略大小写时，找到请求中实际的参数名称再查找
+	if key, ok := foldKey(ctx.HttpRequest(), fieldMeta, src, name); ok {
+		return instance.drawOutExact(ctx, fieldMeta, src, key)
+	}
+
+	return nil, false
 }
 
-func (instance *GmvcBuilder) validateValue(ctx GmvcContext, value interface{}, fieldMeta *ParamMeta, meta *ActionMeta) error {
-	if len(fieldMeta.checkers) <= 0 {
+func (instance *GmvcBuilder) drawOutExact(ctx GmvcContext, fieldMeta *ParamMeta, src Src, name string) (interface{}, bool) {
+	req := ctx.HttpRequest()
+
+	// slice字段收集重复的key
+	switch src {
+	case HeaderSrc:
+		name = http.CanonicalHeaderKey(name)
+		if fieldMeta.multiValued {
+			return req.Header().Gets(name)
+		}
+
+		return req.Header().Get(name)
+	case QuerySrc:
+		if fieldMeta.nested {
+			return drawOutNested(name, req.VisitAllQuery)
+		}
+
+		if fieldMeta.multiValued {
+			return req.GetQueryAll(name)
+		}
+
+		return req.GetQuery(name)
+	case PathSrc:
+		return req.GetPathParam(name)
+	case FormSrc:
+		if fieldMeta.nested {
+			return drawOutNested(name, req.VisitAllPostForm)
+		}
+
+		if fieldMeta.multiValued {
+			return req.GetFormAll(name)
+		}
+
+		return req.GetForm(name)
+	case CookieSrc:
+		return req.GetCookie(name)
+	case CtxSrc:
+		return ctx.GetCtx(name)
+	}
+
+	return nil, false
+}
+
+// validateField 依次执行字段的Checker、跨字段的Checker，然后对每个元素执行dive之后的Checker
+// 字段以及每个元素只报告第一个错误
+func (instance *GmvcBuilder) validateField(ctx GmvcContext, fieldMeta *ParamMeta, field, parent reflect.Value) []*FieldError {
+	// 参数不在请求中且没有default时，Checker得到nil，与零值区分
+	value := field.Interface()
+	if !fieldMeta.isRecursive && len(fieldMeta.autowire) == 0 && !fieldMeta.hasDefault && !ctx.Present(fieldMeta.path) {
+		value = nil
+	}
+
+	if err := runCheckers(ctx, fieldMeta, fieldMeta.checkers, value); err != nil {
+		return []*FieldError{{Field: fieldMeta.fieldName, Err: err}}
+	}
+
+	for _, checker := range fieldMeta.crossCheckers {
+		if err := checker(ctx, fieldMeta, field, parent); err != nil {
+			return []*FieldError{{Field: fieldMeta.fieldName, Err: err}}
+		}
+	}
+
+	elems, ok := unwrapValue(field)
+	if len(fieldMeta.elemCheckers) == 0 || !ok {
 		return nil
 	}
 
-	for _, checker := range fieldMeta.checkers {
+	var failures []*FieldError
+	check := func(key interface{}, elem reflect.Value) {
+		if err := runCheckers(ctx, fieldMeta, fieldMeta.elemCheckers, elem.Interface()); err != nil {
+			failures = append(failures, &FieldError{Field: fmt.Sprintf("%s[%v]", fieldMeta.fieldName, key), Err: err})
+		}
+	}
+
+	switch elems.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < elems.Len(); i++ {
+			check(i, elems.Index(i))
+		}
+	case reflect.Map:
+		keys := elems.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			check(key, elems.MapIndex(key))
+		}
+	}
+
+	return failures
+}
+
+func runCheckers(ctx GmvcContext, fieldMeta *ParamMeta, checkers []Checker, value interface{}) error {
+	for _, checker := range checkers {
 		if checker == nil {
 			continue
 		}
 
-		err := checker(ctx, fieldMeta, value)
-		if err != nil {
+		if err := checker(ctx, fieldMeta, value); err != nil {
 			return err
 		}
 	}
@@ -576,14 +936,23 @@ func (instance *GmvcBuilder) validateValue(ctx GmvcContext, value interface{}, f
 }
 
 // 参数类型转换
-func (instance *GmvcBuilder) convertFieldValue(ctx GmvcContext, fieldMeta *ParamMeta, originValue string, handlerName string) interface{} {
-	value, err := Convert(originValue, fieldMeta.fieldType.Type)
+func (instance *GmvcBuilder) convertFieldValue(ctx GmvcContext, fieldMeta *ParamMeta, originValue string, handlerName string) (interface{}, error) {
+	if fieldMeta.multiValued {
+		return convertValues(fieldMeta, []string{originValue})
+	}
+
+	typ := fieldMeta.fieldType.Type
+	value, err := convert(originValue, typ, fieldMeta.layout)
 	if err != nil {
-		// logs.Error("Overpass. convert err. handler: %s; value: %s", handlerName, originValue)
-		return nil
+		return nil, err
+	}
+
+	// 自定义类型，例如 type Status string
+	if ret := reflect.ValueOf(value); ret.Type() != typ && ret.Type().ConvertibleTo(typ) {
+		return ret.Convert(typ).Interface(), nil
 	}
 
-	return value
+	return value, nil
 }
 
 func (instance *GmvcBuilder) introspect(v reflect.Value) *ActionMeta {
@@ -602,9 +971,12 @@ func (instance *GmvcBuilder) introspect(v reflect.Value) *ActionMeta {
 		field := struct0.Field(i)
 		tagInfo := field.Tag
 		fieldMeta := &ParamMeta{
-			fieldType: field,
-			tagInfo:   tagInfo,
-			fieldName: field.Name,
+			fieldType:   field,
+			tagInfo:     tagInfo,
+			fieldName:   instance.options.naming.name(field.Name),
+			path:        field.Name,
+			multiValued: field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() != reflect.Uint8,
+			sep:         sliceSplit,
 		}
 
 		// Autowire解析，解析到直接返回，不用继续param的解析
@@ -617,7 +989,9 @@ func (instance *GmvcBuilder) introspect(v reflect.Value) *ActionMeta {
 		}
 
 		// xParams解析
-		xParams := strings.Split(tagInfo.Get(XParam), XSplit)
+		renamed := false
+		var auto []Src
+		xParams := splitParam(tagInfo.Get(XParam))
 		for _, value := range xParams {
 			if value == "" {
 				continue
@@ -627,6 +1001,7 @@ func (instance *GmvcBuilder) introspect(v reflect.Value) *ActionMeta {
 				// 如果需要递归解析，则递归下去。
 				fieldMeta.isRecursive = true
 				fieldMeta.handlerMeta = instance.introspect(fieldValue)
+				fieldMeta.handlerMeta.prefixPath(field.Name)
 			case XQuery:
 				fieldMeta.source |= QuerySrc
 			case XForm:
@@ -634,41 +1009,135 @@ func (instance *GmvcBuilder) introspect(v reflect.Value) *ActionMeta {
 			case XBody:
 				fieldMeta.source |= BodySrc
 			case XHeader:
+				// header按照规范的格式匹配，例如x-real-ip匹配X-Real-Ip
 				fieldMeta.source |= HeaderSrc
-				// header 首字母自动大写
-				name := fieldMeta.fieldName
-				for i, v := range name {
-					fieldMeta.fieldName = string(unicode.ToUpper(v)) + name[i+1:]
-					break
-				}
 			case XPath:
 				fieldMeta.source |= PathSrc
 			case XCtx:
 				fieldMeta.source |= CtxSrc
+			case XPrincipal:
+				fieldMeta.source |= PrincipalSrc
+			case XCookie:
+				fieldMeta.source |= CookieSrc
+			case XConfig:
+				fieldMeta.source |= ConfigSrc
 			case XAuto:
 				// 如果是'Auto'，则使用Option中的定义
 				fieldMeta.source |= Src(instance.options.autodef)
+				auto = instance.options.autoOrder
 			default:
+				// Auto(Path,Query)，按照指定的顺序查找
+				if srclist, ok := parseAuto(value); ok {
+					for _, src := range srclist {
+						fieldMeta.source |= src
+					}
+
+					auto = srclist
+					continue
+				}
+
+				if sep, ok := cutPrefix(value, XSep); ok {
+					if sep == XSepNone {
+						sep = ""
+					}
+
+					fieldMeta.sep = sep
+					continue
+				}
+
+				if prefix, ok := cutPrefix(value, XPrefix); ok {
+					fieldMeta.prefix = prefix
+					continue
+				}
+
+				if names, ok := cutPrefix(value, XName); ok {
+					fieldMeta.names = strings.Split(names, XAliasSplit)
+					fieldMeta.fieldName = fieldMeta.names[0]
+					renamed = true
+					continue
+				}
+
 				fieldMeta.fieldName = value
+				fieldMeta.names = nil
+				renamed = true
 			}
 		}
 
-		// xValidator解析
+		// 没有指定名称时，从json等tag中取名称，"-"表示不从按名称查找的来源绑定，Body、Ctx、Principal、Config不受影响
+		tagged := false
+		if name, ok := tagName(field, instance.options.tagNames); ok && !renamed {
+			if name == "" {
+				fieldMeta.source &^= HeaderSrc | QuerySrc | PathSrc | FormSrc | CookieSrc
+			} else {
+				fieldMeta.fieldName = name
+				tagged = true
+			}
+		}
+
+		fieldMeta.derived = instance.options.naming != nil && !renamed && !tagged
+
+		if len(fieldMeta.names) == 0 {
+			fieldMeta.names = []string{fieldMeta.fieldName}
+		}
+
+		fieldMeta.order = sourceOrder(fieldMeta.source, auto)
+
+		// xValidator解析，带参数的是跨字段的Checker，dive之后的Checker检查slice、map的每个元素
 		validatorStr, ok := tagInfo.Lookup(XValidator)
 		if ok {
-			xValidator := strings.Split(validatorStr, XSplit)
-			checkers := make([]Checker, len(xValidator))
-			fieldMeta.checkers = checkers
-			for index, value := range xValidator {
+			xValidator := splitParam(validatorStr)
+			checkers := make([]Checker, 0, len(xValidator))
+			dive := false
+			for _, value := range xValidator {
 				if value == "" {
 					continue
 				}
+
+				// nullable不是Checker，允许required的参数为空值
+				if value == XNullable {
+					fieldMeta.nullable = true
+					continue
+				}
+
+				if value == XDive {
+					switch unwrapType(field.Type).Kind() {
+					case reflect.Slice, reflect.Array, reflect.Map:
+					default:
+						panic(fmt.Sprintf("gmvc: checker dive of field %s.%s needs slice, array or map", struct0.Name(), field.Name))
+					}
+
+					dive = true
+					continue
+				}
+
+				if name, args, ok := parseChecker(value); ok {
+					build, ok := crossCheckers[name]
+					if !ok || dive {
+						panic(fmt.Sprintf("gmvc: unknown checker %s of field %s.%s", value, struct0.Name(), field.Name))
+					}
+
+					checker, err := build(struct0, field, args)
+					if err != nil {
+						panic(fmt.Sprintf("gmvc: checker %s of field %s.%s: %v", value, struct0.Name(), field.Name, err))
+					}
+
+					fieldMeta.crossCheckers = append(fieldMeta.crossCheckers, checker)
+					continue
+				}
+
 				checker := instance.checkerMap[value]
 				if checker == nil {
 					continue
 				}
-				checkers[index] = checker
+
+				if dive {
+					fieldMeta.elemCheckers = append(fieldMeta.elemCheckers, checker)
+				} else {
+					checkers = append(checkers, checker)
+				}
 			}
+
+			fieldMeta.checkers = checkers
 		}
 
 		// Resolver解析
@@ -680,6 +1149,14 @@ func (instance *GmvcBuilder) introspect(v reflect.Value) *ActionMeta {
 			}
 		}
 
+		// struct、map等类型从Query、Form的嵌套参数中解析，例如 filter[status]=open
+		if !fieldMeta.isRecursive && fieldMeta.resolver == nil && fieldMeta.prefix == "" && isNestedType(field.Type) {
+			_, typed := instance.typedResolver[field.Type]
+			fieldMeta.nested = !typed && fieldMeta.source&(QuerySrc|FormSrc) != 0
+		}
+
+		fieldMeta.layout = tagInfo.Get(XLayout)
+
 		// xDefault解析
 		defaultStr, ok := tagInfo.Lookup(XDefault)
 		if ok {
@@ -687,12 +1164,59 @@ func (instance *GmvcBuilder) introspect(v reflect.Value) *ActionMeta {
 			fieldMeta.def = defaultStr
 		}
 
+		instance.checkFieldType(structMeta, fieldMeta)
+		instance.prepareConfig(structMeta, fieldMeta)
 		structMeta.fieldList = append(structMeta.fieldList, fieldMeta)
 	}
 
 	return structMeta
 }
 
+// checkFieldType panics if the field bound from the HTTP request can't be converted,
+// so that unsupported types are reported at BuildAction instead of at request time.
+func (instance *GmvcBuilder) checkFieldType(structMeta *ActionMeta, fieldMeta *ParamMeta) {
+	// Resolver只处理单个字符串，prefix得到的是一组参数
+	if fieldMeta.prefix != "" && fieldMeta.resolver != nil {
+		panic(fmt.Sprintf("gmvc: field %s.%s with prefix can't have a resolver", structMeta.handlerName, fieldMeta.fieldType.Name))
+	}
+
+	if fieldMeta.isRecursive || fieldMeta.resolver != nil || fieldMeta.nested {
+		return
+	}
+
+	// Body整体解析，Ctx、Principal直接赋值，不需要转换
+	if fieldMeta.source&(HeaderSrc|QuerySrc|PathSrc|FormSrc|CookieSrc|ConfigSrc) == 0 && !fieldMeta.hasDefault {
+		return
+	}
+
+	typ := fieldMeta.fieldType.Type
+	if _, ok := instance.typedResolver[typ]; ok {
+		if fieldMeta.prefix != "" {
+			panic(fmt.Sprintf("gmvc: field %s.%s with prefix can't have a typed resolver", structMeta.handlerName, fieldMeta.fieldType.Name))
+		}
+
+		return
+	}
+
+	// interface字段可能是GmvcContext，运行时才能确定，不支持的返回400
+	if typ.Kind() == reflect.Interface {
+		return
+	}
+
+	if fieldMeta.prefix != "" {
+		if !isPrefixType(typ) {
+			panic(fmt.Sprintf("gmvc: field %s.%s with prefix must be map[string]T, got %s", structMeta.handlerName, fieldMeta.fieldType.Name, typ))
+		}
+
+		return
+	}
+
+	if !isConvertible(typ) {
+		panic(fmt.Sprintf("gmvc: unsupported type %s of field %s.%s, register a converter, a typed resolver or a resolver for it",
+			typ, structMeta.handlerName, fieldMeta.fieldType.Name))
+	}
+}
+
 type singletonContext struct {
 	typemap map[reflect.Type]*singleton
 	namemap map[string]*singleton
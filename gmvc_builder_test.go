@@ -1,7 +1,9 @@
 package gmvc
 
 import (
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,7 +12,7 @@ import (
 func TestDefaultGmvcBuilder(t *testing.T) {
 	builder := CreateGmvcBuilder()
 	assert.True(t, len(builder.resolverMap) == 1)
-	assert.True(t, len(builder.responsor) == 3)
+	assert.True(t, len(builder.responsor) == 10)
 }
 
 func TestRegisterTypedResolver(t *testing.T) {
@@ -27,3 +29,22 @@ func TestRegisterTypedResolver(t *testing.T) {
 
 	assert.True(t, len(mapp) == 2)
 }
+
+type manualAction struct {
+	Ctx GmvcContext
+}
+
+func (a *manualAction) Go() (interface{}, error) {
+	a.Ctx.HttpResponse().Status(http.StatusAccepted)
+	a.Ctx.HttpResponse().Body(strings.NewReader("manual"))
+	return nil, nil
+}
+
+func TestCommittedResponse(t *testing.T) {
+	ctx := newMockContext(http.MethodGet, "/")
+	serve(CreateGmvcBuilder(), &manualAction{}, ctx)
+
+	assert.Equal(t, http.StatusAccepted, ctx.resp.StatusCode())
+	assert.Equal(t, "manual", bodyString(ctx))
+	assert.Equal(t, int64(6), ctx.resp.Written())
+}
@@ -28,6 +28,16 @@ func (meta ActionMeta) GetFieldMeta() []*ParamMeta {
 	return meta.fieldList
 }
 
+// prefixPath 给Recursive结构体以及更深层的字段路径加上前缀
+func (meta *ActionMeta) prefixPath(prefix string) {
+	for _, fieldMeta := range meta.fieldList {
+		fieldMeta.path = prefix + "." + fieldMeta.path
+		if fieldMeta.handlerMeta != nil {
+			fieldMeta.handlerMeta.prefixPath(prefix)
+		}
+	}
+}
+
 func (meta ActionMeta) GetAutowireInstances() map[string]any {
 	autowire := make(map[string]any, 0)
 	for _, fieldMeta := range meta.fieldList {
@@ -55,6 +65,9 @@ type ParamMeta struct {
 	// Field的名字，用于从Query、Body、Header中找值
 	fieldName string
 
+	// Field在Action中的路径，Recursive结构体的字段带上结构体的名字，例如 Owner.Age，见GmvcContext.Present
+	path string
+
 	// 是否需要递归解析，如果有继承结构体的情况下，可能需要递归解析。
 	isRecursive bool
 
@@ -64,6 +77,15 @@ type ParamMeta struct {
 	// Validators
 	checkers []Checker
 
+	// 跨字段的Checker，例如 checker:"gtfield(StartTime)"
+	crossCheckers []crossChecker
+
+	// slice、map每个元素的Checker，例如 checker:"dive,notempty"
+	elemCheckers []Checker
+
+	// required的参数是否允许空值，例如 ?name=
+	nullable bool
+
 	// Resolver
 	resolver Resolver
 
@@ -76,6 +98,33 @@ type ParamMeta struct {
 	// default值
 	def string
 
+	// slice字段（[]byte除外），重复的key收集为多个值
+	multiValued bool
+
+	// slice字段每个值的分隔符，为空则不分隔
+	sep string
+
+	// 静态配置在BuildAction时查找的值，见UseConfig
+	config *configValue
+
+	// 参数来源的查找顺序
+	order []Src
+
+	// 参数名称以及别名，依次查找，例如 param:"Query,name=user_id|userId"
+	names []string
+
+	// 参数名称由命名策略生成，不作用于Header和Ctx
+	derived bool
+
+	// map字段，收集key以prefix开头的参数，例如 param:"Header,prefix=X-Meta-"
+	prefix string
+
+	// 时间字段的layout，见LayoutUnix
+	layout string
+
+	// struct、map等字段，从Query、Form的嵌套参数中解析，例如 filter[status]=open
+	nested bool
+
 	autowire string
 }
 
@@ -87,6 +136,10 @@ func (meta ParamMeta) GetName() string {
 	return meta.fieldName
 }
 
+func (meta ParamMeta) GetPath() string {
+	return meta.path
+}
+
 func (meta ParamMeta) GetType() reflect.Type {
 	return meta.fieldType.Type
 }
@@ -103,6 +156,10 @@ func (meta ParamMeta) GetValidators() []Checker {
 	return meta.checkers
 }
 
+func (meta ParamMeta) GetNullable() bool {
+	return meta.nullable
+}
+
 func (meta ParamMeta) GetDefault() string {
 	return meta.def
 }
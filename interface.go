@@ -3,6 +3,7 @@ package gmvc
 import (
 	"context"
 	"io"
+	"net/http"
 	"net/url"
 )
 
@@ -39,6 +40,14 @@ type (
 		// 参数报道
 		Report(name string)
 
+		// Present reports whether the field of the Action is bound from the request, eg. to update only the fields
+		// sent by the client in Init. Default values are not counted. The field is the Go field name, fields of
+		// Recursive structs are prefixed by the struct fields, eg. "Owner.Age".
+		Present(field string) bool
+
+		// MarkPresent marks the field present by its path, see [ParamMeta.GetPath], it's called while binding.
+		MarkPresent(field string)
+
 		// set context
 		Set(key string, value interface{})
 
@@ -73,6 +82,10 @@ type (
 		// If the key does not exist, it returns ("", false).
 		GetQuery(key string) (string, bool)
 
+		// GetQueryAll returns all the query parameter values for the named key, eg. "?id=1&id=2".
+		// If the key does not exist, it returns (nil, false).
+		GetQueryAll(key string) ([]string, bool)
+
 		// GetPostForm returns the post form parameter value for the named key.
 		// If the key does not exist, it returns ("", false).
 		GetPostForm(key string) (string, bool)
@@ -81,16 +94,30 @@ type (
 		// If the key does not exist, it returns ("", false).
 		GetForm(key string) (string, bool)
 
+		// GetFormAll returns all the form parameter values for the named key.
+		// If the key does not exist, it returns (nil, false).
+		GetFormAll(key string) ([]string, bool)
+
 		// GetPathParam returns the path parameter value for the named key.
 		// If the key does not exist, it returns ("", false).
 		GetPathParam(key string) (string, bool)
 
+		// VisitAllPathParam visits all path parameters.
+		VisitAllPathParam(func(key, value string))
+
 		// VisitAllPostForm visits all post form parameters.
 		VisitAllPostForm(func(key, value string))
 
 		// VisitAllQuery visits all query parameters.
 		VisitAllQuery(func(key, value string))
 
+		// GetCookie returns the cookie value for the named key.
+		// If the cookie does not exist, it returns ("", false).
+		GetCookie(key string) (string, bool)
+
+		// VisitAllCookie visits all cookies of the request.
+		VisitAllCookie(func(key, value string))
+
 		// Body returns the request body.
 		// FIXME: not sure if it is a good idea to return []byte. or maybe io.Reader is better.
 		Body() []byte
@@ -108,11 +135,39 @@ type (
 		// Header returns the response header.
 		Header() Header
 
-		// SetHeader sets the HTTP response header.
+		// SetHeader sets the HTTP response header, an empty value removes the header.
 		SetHeader(key, value string)
 
 		// Body sets the HTTP response body.
 		Body(io.Reader)
+
+		// StatusCode returns the HTTP response status code.
+		StatusCode() int
+
+		// Committed reports whether the response body has been written,
+		// either by gmvc or directly by the underlying web framework, eg. through [GmvcContext.GetEntity].
+		// Once committed, gmvc will not write the response again.
+		Committed() bool
+
+		// Written returns the number of body bytes written so far.
+		Written() int64
+
+		// SetCookie adds a `Set-Cookie` header, cookies of different names are all kept.
+		SetCookie(cookie *http.Cookie)
+
+		// Writer returns the writer sending the body directly to the client with chunked transfer,
+		// the status and header are sent on the first write.
+		// It is used by responses pushing data incrementally, eg. Server-Sent Events,
+		// and must not be mixed with [HttpResponse.Body].
+		Writer() ResponseWriter
+	}
+
+	// ResponseWriter writes the response body directly to the client.
+	ResponseWriter interface {
+		io.Writer
+
+		// Flush sends the buffered data to the client.
+		Flush() error
 	}
 
 	// Header interface represents the HTTP header.
@@ -144,8 +199,16 @@ type Action interface {
 	Go() (interface{}, error)
 }
 
+// Validator is implemented by Actions validating across the fields, eg. the end time must be after the start time.
+// Validate is invoked after the parameters are bound and checked by the Checkers, and before Init.
+// Errors other than [HTTPError], [ValidationError] and [FieldError] are answered with 400 "validation_failed".
+type Validator interface {
+	Validate(ctx GmvcContext) error
+}
+
 // Checker is the validator function.
-// It will be invoked after the parameters are resolved.
+// It will be invoked after the parameters are resolved, value is the value of the field after binding,
+// or nil if the parameter is absent from the request and has no default.
 // If the validator returns an error, the request will be aborted and the error will be returned to the client.
 // Any error during gmvc runtime will be catched by [HandleError].
 type Checker func(ctx GmvcContext, fieldMeta *ParamMeta, value interface{}) error
@@ -159,11 +222,13 @@ type Resolver func(ctx GmvcContext, fieldMeta *ParamMeta, origin string) (interf
 // HandleError is the global error handler.
 // If any error occurs during gmvc runtime, it will be catched by this handler.
 // This handler convert the error to a response.
+// By default, [DefaultErrorHandler] is used.
 type HandleError func(ctx GmvcContext, err error) interface{}
 
 // HandlerFunc defines the general handler function.
 type HandlerFunc func(ctx GmvcContext)
 
-// RecoverFunc is invoked when gmvc catches a panic.
+// RecoverFunc is invoked when gmvc catches a panic, info is the value passed to panic.
 // Any error during gmvc runtime will be catched by [HandleError].
+// Without RecoverFunc, the panic is passed to [HandleError] as a [PanicError].
 type RecoverFunc func(ctx GmvcContext, info interface{}) (interface{}, error)
@@ -13,3 +13,15 @@ type ILogger interface {
 	Info(ctx context.Context, msg string, vars ...interface{})
 	Error(ctx context.Context, msg string, vars ...interface{})
 }
+
+func logError(ctx context.Context, msg string, vars ...interface{}) {
+	if logger != nil {
+		logger.Error(ctx, msg, vars...)
+	}
+}
+
+func logInfo(ctx context.Context, msg string, vars ...interface{}) {
+	if logger != nil {
+		logger.Info(ctx, msg, vars...)
+	}
+}
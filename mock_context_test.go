@@ -0,0 +1,246 @@
+package gmvc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var _ GmvcContext = (*mockContext)(nil)
+
+// mockContext is an in-memory GmvcContext for tests.
+type mockContext struct {
+	context.Context
+
+	req  *mockRequest
+	resp *mockResponse
+
+	values   map[string]interface{}
+	paramSet map[string]struct{}
+	present  map[string]struct{}
+
+	action     any
+	actionMeta *ActionMeta
+}
+
+func newMockContext(method, target string) *mockContext {
+	u, _ := url.ParseRequestURI(target)
+	return &mockContext{
+		Context: context.Background(),
+		req: &mockRequest{
+			method: method,
+			url:    u,
+			header: mockHeader{},
+			path:   map[string]string{},
+			form:   url.Values{},
+		},
+		resp: &mockResponse{
+			status: http.StatusOK,
+			header: mockHeader{},
+		},
+		values:   map[string]interface{}{},
+		paramSet: map[string]struct{}{},
+		present:  map[string]struct{}{},
+	}
+}
+
+func (m *mockContext) HttpRequest() HttpRequest          { return m.req }
+func (m *mockContext) HttpResponse() HttpResponse        { return m.resp }
+func (m *mockContext) ActionMeta() *ActionMeta           { return m.actionMeta }
+func (m *mockContext) Action() any                       { return m.action }
+func (m *mockContext) SetActionMeta(meta *ActionMeta)    { m.actionMeta = meta }
+func (m *mockContext) SetAction(action any)              { m.action = action }
+func (m *mockContext) Set(key string, value interface{}) { m.values[key] = value }
+func (m *mockContext) GetEntity() interface{}            { return m }
+func (m *mockContext) Report(name string)                { m.paramSet[name] = struct{}{} }
+func (m *mockContext) MarkPresent(field string)          { m.present[field] = struct{}{} }
+
+func (m *mockContext) GetCtx(key string) (interface{}, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+func (m *mockContext) HasParam(name string) bool {
+	_, ok := m.paramSet[name]
+	return ok
+}
+
+func (m *mockContext) Present(field string) bool {
+	_, ok := m.present[field]
+	return ok
+}
+
+type mockRequest struct {
+	method string
+	url    *url.URL
+	header mockHeader
+	path   map[string]string
+	form   url.Values
+	body   []byte
+}
+
+func (r *mockRequest) Method() string      { return r.method }
+func (r *mockRequest) Host() string        { return r.url.Host }
+func (r *mockRequest) ContentLength() int  { return len(r.body) }
+func (r *mockRequest) URL() *url.URL       { return r.url }
+func (r *mockRequest) Header() Header      { return r.header }
+func (r *mockRequest) Body() []byte        { return r.body }
+func (r *mockRequest) ContentType() string { v, _ := r.header.Get("Content-Type"); return v }
+
+func (r *mockRequest) GetQuery(key string) (string, bool) {
+	values, ok := r.url.Query()[key]
+	if !ok {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+func (r *mockRequest) GetQueryAll(key string) ([]string, bool) {
+	values, ok := r.url.Query()[key]
+	return values, ok
+}
+
+func (r *mockRequest) GetPostForm(key string) (string, bool) {
+	values, ok := r.form[key]
+	if !ok {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+func (r *mockRequest) GetForm(key string) (string, bool) {
+	return r.GetPostForm(key)
+}
+
+func (r *mockRequest) GetFormAll(key string) ([]string, bool) {
+	values, ok := r.form[key]
+	return values, ok
+}
+
+func (r *mockRequest) GetPathParam(key string) (string, bool) {
+	v, ok := r.path[key]
+	return v, ok
+}
+
+func (r *mockRequest) VisitAllPathParam(f func(key, value string)) {
+	for k, v := range r.path {
+		f(k, v)
+	}
+}
+
+func (r *mockRequest) VisitAllPostForm(f func(key, value string)) {
+	for k, values := range r.form {
+		for _, v := range values {
+			f(k, v)
+		}
+	}
+}
+
+func (r *mockRequest) VisitAllQuery(f func(key, value string)) {
+	for k, values := range r.url.Query() {
+		for _, v := range values {
+			f(k, v)
+		}
+	}
+}
+
+func (r *mockRequest) GetCookie(key string) (string, bool) {
+	cookie, err := (&http.Request{Header: http.Header(r.header)}).Cookie(key)
+	if err != nil {
+		return "", false
+	}
+
+	return cookie.Value, true
+}
+
+func (r *mockRequest) VisitAllCookie(f func(key, value string)) {
+	for _, cookie := range (&http.Request{Header: http.Header(r.header)}).Cookies() {
+		f(cookie.Name, cookie.Value)
+	}
+}
+
+type mockResponse struct {
+	status    int
+	header    mockHeader
+	body      bytes.Buffer
+	committed bool
+	flushes   int
+}
+
+func (r *mockResponse) HTML(status int, body string, model any) {}
+func (r *mockResponse) Status(code int)                         { r.status = code }
+func (r *mockResponse) Header() Header                          { return r.header }
+func (r *mockResponse) SetHeader(key, value string) {
+	if value == "" {
+		http.Header(r.header).Del(key)
+		return
+	}
+
+	r.header.set(key, value)
+}
+
+func (r *mockResponse) SetCookie(cookie *http.Cookie) {
+	http.Header(r.header).Add("Set-Cookie", cookie.String())
+}
+
+func (r *mockResponse) StatusCode() int { return r.status }
+func (r *mockResponse) Committed() bool { return r.committed }
+func (r *mockResponse) Written() int64  { return int64(r.body.Len()) }
+
+func (r *mockResponse) Body(in io.Reader) {
+	r.committed = true
+	r.body.Reset()
+	_, _ = io.Copy(&r.body, in)
+}
+
+func (r *mockResponse) Writer() ResponseWriter {
+	r.committed = true
+	return (*mockWriter)(r)
+}
+
+type mockWriter mockResponse
+
+func (w *mockWriter) Write(p []byte) (int, error) { return w.body.Write(p) }
+func (w *mockWriter) Flush() error                { w.flushes++; return nil }
+
+type mockHeader map[string][]string
+
+func (h mockHeader) set(key, value string) {
+	http.Header(h).Set(key, value)
+}
+
+func (h mockHeader) Get(key string) (string, bool) {
+	values, ok := h.Gets(key)
+	if !ok {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+func (h mockHeader) Gets(key string) ([]string, bool) {
+	values, ok := h[http.CanonicalHeaderKey(key)]
+	return values, ok
+}
+
+func (h mockHeader) VisitAll(f func(k, v []byte)) {
+	for k, values := range h {
+		for _, v := range values {
+			f([]byte(k), []byte(v))
+		}
+	}
+}
+
+// serve builds the action and runs it against the mock context.
+func serve(builder *GmvcBuilder, action Action, ctx *mockContext, midware ...IMiddleware) {
+	builder.BuildAction(action, midware...)(ctx)
+}
+
+func bodyString(ctx *mockContext) string {
+	return strings.TrimSpace(ctx.resp.body.String())
+}
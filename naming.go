@@ -0,0 +1,148 @@
+package gmvc
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives the parameter name from the field name, see [Naming].
+// Fields renamed by the param tag are not affected.
+type NamingStrategy func(field string) string
+
+var (
+	// SnakeCase names UserID as user_id.
+	SnakeCase NamingStrategy = func(field string) string {
+		return strings.ToLower(strings.Join(splitWords(field), "_"))
+	}
+
+	// KebabCase names UserID as user-id.
+	KebabCase NamingStrategy = func(field string) string {
+		return strings.ToLower(strings.Join(splitWords(field), "-"))
+	}
+
+	// CamelCase names UserID as userId.
+	CamelCase NamingStrategy = func(field string) string {
+		words := splitWords(field)
+		for i, word := range words {
+			word = strings.ToLower(word)
+			if i > 0 {
+				word = strings.ToUpper(word[:1]) + word[1:]
+			}
+
+			words[i] = word
+		}
+
+		return strings.Join(words, "")
+	}
+)
+
+func (n NamingStrategy) name(field string) string {
+	if n == nil {
+		return field
+	}
+
+	return n(field)
+}
+
+// splitWords splits the Go identifier into words, acronyms are kept together,
+// eg. "HTTPServerID" into ["HTTP", "Server", "ID"], "userId" into ["user", "Id"].
+func splitWords(name string) []string {
+	runes := []rune(name)
+
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		switch {
+		case cur == '_' || cur == '-':
+			// 已有的分隔符
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+
+			start = i + 1
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			// userId -> user, Id
+			words = append(words, string(runes[start:i]))
+			start = i
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// HTTPServer -> HTTP, Server
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// tagName returns the name of the field in the first of the tags, eg. "user_id" of `json:"user_id,omitempty"`.
+// It returns "" if the field is ignored by the tag, and false if none of the tags names the field.
+func tagName(field reflect.StructField, tags []string) (string, bool) {
+	for _, tag := range tags {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		// json:"-"忽略字段，json:"-,"的名称为"-"
+		if value == "-" {
+			return "", true
+		}
+
+		if name, _, _ := strings.Cut(value, ","); name != "" {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// foldKey finds the key of the parameter in the request matching the name case-insensitively,
+// nested keys are matched by the name before '[' or '.', eg. "Filter[status]" for "filter".
+func foldKey(req HttpRequest, fieldMeta *ParamMeta, src Src, name string) (string, bool) {
+	var visits []func(f func(key, value string))
+	switch src {
+	case QuerySrc:
+		visits = append(visits, req.VisitAllQuery)
+	case FormSrc:
+		visits = append(visits, req.VisitAllQuery, req.VisitAllPostForm)
+	case PathSrc:
+		visits = append(visits, req.VisitAllPathParam)
+	case CookieSrc:
+		visits = append(visits, req.VisitAllCookie)
+	}
+
+	found, ok := "", false
+	for _, visit := range visits {
+		visit(func(key, value string) {
+			if ok {
+				return
+			}
+
+			base := key
+			if fieldMeta.nested {
+				if i := strings.IndexAny(key, "[."); i >= 0 {
+					base = key[:i]
+				}
+			}
+
+			if strings.EqualFold(base, name) {
+				found, ok = base, true
+			}
+		})
+
+		if ok {
+			break
+		}
+	}
+
+	return found, ok
+}
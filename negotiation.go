@@ -0,0 +1,128 @@
+package gmvc
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Producer is implemented by Actions which decide the RenderTypes they can produce,
+// overriding the ones defined by [Negotiate]. Content negotiation is enabled for such Actions.
+type Producer interface {
+	Produces() []RenderType
+}
+
+// mediaRange is a media range of the `Accept` header, eg. "text/*;q=0.8".
+type mediaRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// specificity ranks "type/subtype" over "type/*" over "*/*".
+func (r mediaRange) specificity() int {
+	switch {
+	case r.typ == "*":
+		return 0
+	case r.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (r mediaRange) match(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	return (r.typ == "*" || strings.EqualFold(r.typ, typ)) &&
+		(r.subtype == "*" || strings.EqualFold(r.subtype, subtype))
+}
+
+// parseAccept parses the `Accept` header, the ranges are sorted by quality then by specificity.
+// Malformed ranges are ignored.
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0, 4)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.TrimSpace(params[0])
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+
+		r := mediaRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(k, "q") {
+				q, err := strconv.ParseFloat(v, 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+
+				r.q = q
+			}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+// quality returns the quality the client accepts the media type with, by the most specific matching range.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	best, q := -1, 0.0
+	for _, r := range ranges {
+		if r.match(mediaType) && r.specificity() > best {
+			best, q = r.specificity(), r.q
+		}
+	}
+
+	return q
+}
+
+// negotiate chooses the render of the highest quality from candidates, ties are broken by the order of candidates.
+// Candidates which can't encode the body are skipped. It returns false if none of the candidates is acceptable.
+func (gmvc *GmvcBuilder) negotiate(accept string, candidates []RenderType, body any) (RenderType, bool) {
+	anything := strings.TrimSpace(accept) == ""
+	ranges := parseAccept(accept)
+	chosen, best := RenderType(0), 0.0
+	for _, render := range candidates {
+		responsor, ok := gmvc.responsor[render]
+		if !ok {
+			continue
+		}
+
+		if selective, ok := responsor.(selectiveResponsor); ok && !selective.accepts(body) {
+			continue
+		}
+
+		if anything {
+			return render, true
+		}
+
+		for _, mediaType := range responsor.MediaTypes() {
+			if q := quality(ranges, mediaType); q > best {
+				chosen, best = render, q
+			}
+		}
+	}
+
+	return chosen, best > 0
+}
+
+// candidates returns the renders to negotiate for the current Action, or nil if negotiation is disabled.
+func (gmvc *GmvcBuilder) candidates(ctx GmvcContext) []RenderType {
+	if producer, ok := ctx.Action().(Producer); ok {
+		return producer.Produces()
+	}
+
+	return gmvc.options.negotiable
+}
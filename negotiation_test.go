@@ -0,0 +1,78 @@
+package gmvc
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccept(t *testing.T) {
+	ranges := parseAccept("text/*;q=0.3, text/html;q=0.7, text/html;level=1, */*;q=0.5, invalid")
+	assert.Len(t, ranges, 4)
+	assert.Equal(t, "html", ranges[0].subtype)
+	assert.Equal(t, 1.0, ranges[0].q)
+
+	assert.Equal(t, 1.0, quality(ranges, "text/html"))
+	assert.Equal(t, 0.3, quality(ranges, "text/plain"))
+	assert.Equal(t, 0.5, quality(ranges, "image/jpeg"))
+
+	assert.Equal(t, 0.0, quality(parseAccept("application/json;q=0"), "application/json"))
+}
+
+type producerAction struct{}
+
+func (a *producerAction) Produces() []RenderType {
+	return []RenderType{JSON, String}
+}
+
+func (a *producerAction) Go() (interface{}, error) {
+	return "hello", nil
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{accept: "", status: http.StatusOK, contentType: "application/json", body: `"hello"`},
+		{accept: "*/*", status: http.StatusOK, contentType: "application/json", body: `"hello"`},
+		{accept: "text/plain", status: http.StatusOK, contentType: "text/plain", body: `hello`},
+		{accept: "application/json;q=0.5, text/*", status: http.StatusOK, contentType: "text/plain", body: `hello`},
+		{accept: "application/xml", status: http.StatusNotAcceptable, contentType: "application/json"},
+	}
+
+	builder := CreateGmvcBuilder()
+	for _, c := range cases {
+		t.Run(c.accept, func(t *testing.T) {
+			ctx := newMockContext(http.MethodGet, "/")
+			if c.accept != "" {
+				ctx.req.header.set("Accept", c.accept)
+			}
+
+			serve(builder, &producerAction{}, ctx)
+			assert.Equal(t, c.status, ctx.resp.status)
+
+			contentType, _ := ctx.resp.header.Get("Content-Type")
+			assert.Equal(t, c.contentType, contentType)
+
+			vary, _ := ctx.resp.header.Get("Vary")
+			assert.Equal(t, "Accept", vary)
+			if c.body != "" {
+				assert.Equal(t, c.body, bodyString(ctx))
+			}
+		})
+	}
+}
+
+func TestNegotiateOption(t *testing.T) {
+	builder := CreateGmvcBuilder(Negotiate(String, JSON))
+
+	ctx := newMockContext(http.MethodGet, "/?Age=18")
+	ctx.req.header.set("Accept", "text/plain, application/json")
+	serve(builder, &errorAction{}, ctx)
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, "18", bodyString(ctx))
+}
@@ -0,0 +1,376 @@
+package gmvc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultNestedDepth 嵌套参数默认的最大深度
+	defaultNestedDepth = 5
+
+	// defaultNestedElements 嵌套参数默认的最大元素个数
+	defaultNestedElements = 1000
+)
+
+var (
+	// ErrNestedDepth means the nested parameter is deeper than the limit, see [NestedLimit].
+	ErrNestedDepth = errors.New("nested parameter too deep")
+
+	// ErrNestedElements means the nested parameter has more elements than the limit, see [NestedLimit].
+	ErrNestedElements = errors.New("nested parameter has too many elements")
+)
+
+// nestedValues are the key/value pairs of the query or form whose keys start with the parameter name,
+// in the order of the request, eg. "filter[status]=open" and "filter[tags][]=a" of the "filter" parameter.
+type nestedValues struct {
+	name  string
+	pairs [][2]string
+}
+
+// nestedNode is the tree built from the nested keys, leaves hold the values.
+type nestedNode struct {
+	values   []string
+	keys     []string
+	children map[string]*nestedNode
+}
+
+func (n *nestedNode) child(key string) *nestedNode {
+	if n.children == nil {
+		n.children = make(map[string]*nestedNode)
+	}
+
+	c, ok := n.children[key]
+	if !ok {
+		c = &nestedNode{}
+		n.children[key] = c
+		n.keys = append(n.keys, key)
+	}
+
+	return c
+}
+
+// lookup finds the child by the name, case-insensitively if there is no exact match.
+func (n *nestedNode) lookup(name string) *nestedNode {
+	if c, ok := n.children[name]; ok {
+		return c
+	}
+
+	for _, key := range n.keys {
+		if strings.EqualFold(key, name) {
+			return n.children[key]
+		}
+	}
+
+	return nil
+}
+
+// isNestedType reports whether the type is bound from nested keys, that is struct, map,
+// or slice of them, pointers are dereferenced.
+func isNestedType(typ reflect.Type) bool {
+	if hasConverter(typ) {
+		return false
+	}
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice:
+		elem := typ.Elem()
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+
+		return !hasConverter(elem) && (elem.Kind() == reflect.Struct || elem.Kind() == reflect.Map)
+	}
+
+	return false
+}
+
+// drawOutNested collects the nested keys of the parameter.
+func drawOutNested(name string, visit func(f func(key, value string))) (nestedValues, bool) {
+	values := nestedValues{name: name}
+	visit(func(key, value string) {
+		if key == name || strings.HasPrefix(key, name+"[") || strings.HasPrefix(key, name+".") {
+			values.pairs = append(values.pairs, [2]string{key, value})
+		}
+	})
+
+	return values, len(values.pairs) > 0
+}
+
+// decodeNested builds the value of the field from the nested keys, both bracket and dot notation are supported:
+//
+//	filter[status]=open&filter[tags][]=a&items[0].name=x
+//
+// Indexes of slices are only used for ordering, so that "items[1000000]" doesn't allocate a huge slice.
+func (gmvc *GmvcBuilder) decodeNested(fieldMeta *ParamMeta, values nestedValues) (interface{}, error) {
+	limits := gmvc.options.nested
+
+	if len(values.pairs) > limits.elements {
+		return nil, &FieldError{Field: fieldMeta.fieldName, Err: ErrNestedElements}
+	}
+
+	root := &nestedNode{}
+	for _, kv := range values.pairs {
+		segments, err := parseNestedKey(kv[0][len(values.name):])
+		if err != nil {
+			return nil, &FieldError{Field: kv[0], Value: kv[1], Err: err}
+		}
+
+		if len(segments) > limits.depth {
+			return nil, &FieldError{Field: kv[0], Value: kv[1], Err: ErrNestedDepth}
+		}
+
+		node := root
+		for i, segment := range segments {
+			// "[]"只能在末尾，表示追加到slice
+			if segment == "" {
+				if i != len(segments)-1 {
+					return nil, &FieldError{Field: kv[0], Value: kv[1], Err: fmt.Errorf("malformed key %q", kv[0])}
+				}
+
+				break
+			}
+
+			node = node.child(segment)
+		}
+
+		node.values = append(node.values, kv[1])
+	}
+
+	value, err := gmvc.decodeNestedNode(root, fieldMeta.fieldType.Type, values.name, fieldMeta.layout)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.Interface(), nil
+}
+
+// parseNestedKey splits the key after the parameter name into segments, eg. "[items][0].name" into ["items", "0", "name"].
+// An empty segment means "[]".
+func parseNestedKey(key string) ([]string, error) {
+	var segments []string
+	for rest := key; rest != ""; {
+		switch rest[0] {
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("malformed key, missing ']' in %q", key)
+			}
+
+			segments = append(segments, rest[1:end])
+			rest = rest[end+1:]
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			if end == 0 {
+				return nil, fmt.Errorf("malformed key, empty name in %q", key)
+			}
+
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("malformed key %q", key)
+		}
+	}
+
+	return segments, nil
+}
+
+func (gmvc *GmvcBuilder) decodeNestedNode(node *nestedNode, typ reflect.Type, path string, layout string) (reflect.Value, error) {
+	kind := typ.Kind()
+	if hasConverter(typ) {
+		kind = reflect.Invalid
+	}
+
+	switch kind {
+	case reflect.Pointer:
+		elem, err := gmvc.decodeNestedNode(node, typ.Elem(), path, layout)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	case reflect.Struct:
+		out := reflect.New(typ).Elem()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			var (
+				name  string
+				child *nestedNode
+			)
+
+			for _, name = range gmvc.paramNames(field) {
+				if child = node.lookup(name); child != nil {
+					break
+				}
+			}
+
+			if child == nil {
+				continue
+			}
+
+			value, err := gmvc.decodeNestedNode(child, field.Type, path+"["+name+"]", field.Tag.Get(XLayout))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			out.Field(i).Set(value)
+		}
+
+		return out, nil
+	case reflect.Map:
+		out := reflect.MakeMapWithSize(typ, len(node.keys))
+		for _, key := range node.keys {
+			k, err := convertNestedScalar(key, typ.Key(), "")
+			if err != nil {
+				return reflect.Value{}, &FieldError{Field: path, Value: key, Err: err}
+			}
+
+			v, err := gmvc.decodeNestedNode(node.children[key], typ.Elem(), path+"["+key+"]", layout)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			out.SetMapIndex(k, v)
+		}
+
+		return out, nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+
+		// 下标只用于排序，"01"和"1"视为不同的元素
+		keys := make([]string, len(node.keys))
+		indexes := make(map[string]int, len(node.keys))
+		for i, key := range node.keys {
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 {
+				return reflect.Value{}, &FieldError{Field: path, Value: key, Err: fmt.Errorf("invalid index %q", key)}
+			}
+
+			keys[i] = key
+			indexes[key] = index
+		}
+
+		sort.SliceStable(keys, func(i, j int) bool { return indexes[keys[i]] < indexes[keys[j]] })
+		out := reflect.MakeSlice(typ, 0, len(keys)+len(node.values))
+		for _, key := range keys {
+			v, err := gmvc.decodeNestedNode(node.children[key], typ.Elem(), path+"["+key+"]", layout)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+
+			out = reflect.Append(out, v)
+		}
+
+		for _, value := range node.values {
+			v, err := convertNestedScalar(value, typ.Elem(), layout)
+			if err != nil {
+				return reflect.Value{}, &FieldError{Field: path, Value: value, Err: err}
+			}
+
+			out = reflect.Append(out, v)
+		}
+
+		return out, nil
+	}
+
+	if len(node.keys) > 0 {
+		return reflect.Value{}, &FieldError{Field: path, Err: fmt.Errorf("unexpected nested keys for %s", typ)}
+	}
+
+	if len(node.values) == 0 {
+		return reflect.Zero(typ), nil
+	}
+
+	v, err := convertNestedScalar(node.values[0], typ, layout)
+	if err != nil {
+		return reflect.Value{}, &FieldError{Field: path, Value: node.values[0], Err: err}
+	}
+
+	return v, nil
+}
+
+// convertNestedScalar converts the value to the type, named types such as "type Status string" are supported.
+func convertNestedScalar(origin string, typ reflect.Type, layout string) (reflect.Value, error) {
+	if typ.Kind() == reflect.Pointer {
+		elem, err := convertNestedScalar(origin, typ.Elem(), layout)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+	}
+
+	if value, ok, err := convertValue(origin, typ, layout); ok {
+		return value, err
+	}
+
+	if _, ok := convertMap[typ.Kind()]; !ok {
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", typ)
+	}
+
+	value, err := convert(origin, typ, layout)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return reflect.ValueOf(value).Convert(typ), nil
+}
+
+// paramNames returns the names of the field in the param tag, or the name derived from the field name if not renamed.
+func (gmvc *GmvcBuilder) paramNames(field reflect.StructField) []string {
+	for _, value := range splitParam(field.Tag.Get(XParam)) {
+		if strings.HasPrefix(value, XAuto+"(") {
+			continue
+		}
+
+		switch value {
+		case "", XRecursive, XQuery, XForm, XBody, XHeader, XPath, XCtx, XPrincipal, XCookie, XConfig, XAuto:
+			continue
+		}
+
+		if strings.HasPrefix(value, XSep) || strings.HasPrefix(value, XPrefix) {
+			continue
+		}
+
+		if names, ok := cutPrefix(value, XName); ok {
+			return strings.Split(names, XAliasSplit)
+		}
+
+		return []string{value}
+	}
+
+	if name, ok := tagName(field, gmvc.options.tagNames); ok {
+		if name == "" {
+			return nil
+		}
+
+		return []string{name}
+	}
+
+	return []string{gmvc.options.naming.name(field.Name)}
+}
@@ -0,0 +1,112 @@
+package gmvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+// optional is implemented by *Optional[T], so that the converters can bind it without knowing T.
+type optional interface {
+	elemType() reflect.Type
+	setValue(v reflect.Value)
+	get() (reflect.Value, bool)
+}
+
+// Optional is the field whose presence matters, eg. PATCH requests updating only the fields sent by the client.
+// It's set when the parameter is bound, even with the zero value or the default, and unset when the parameter is absent:
+//
+//	type PatchUserAction struct {
+//		Name Optional[string] `param:"Form"`
+//		Age  Optional[int]    `param:"Form"`
+//	}
+//
+//	if age, ok := a.Age.Value(); ok {
+//		user.Age = age
+//	}
+//
+// In JSON, an unset Optional is encoded as null, and null is decoded as set with the zero value.
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns the Optional set with v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{value: v, set: true}
+}
+
+// Set sets the value.
+func (o *Optional[T]) Set(v T) {
+	o.value = v
+	o.set = true
+}
+
+// Value returns the value, and whether it's set.
+func (o Optional[T]) Value() (T, bool) {
+	return o.value, o.set
+}
+
+// IsSet reports whether the value is set.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// OrElse returns the value if it's set, otherwise def.
+func (o Optional[T]) OrElse(def T) T {
+	if !o.set {
+		return def
+	}
+
+	return o.value
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	var v T
+	if !bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+	}
+
+	o.Set(v)
+	return nil
+}
+
+func (o *Optional[T]) elemType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (o *Optional[T]) setValue(v reflect.Value) {
+	o.Set(v.Interface().(T))
+}
+
+func (o *Optional[T]) get() (reflect.Value, bool) {
+	return reflect.ValueOf(&o.value).Elem(), o.set
+}
+
+// convertOptional converts the value to the element type of the Optional, and sets it.
+func convertOptional(origin string, typ reflect.Type, layout string) (interface{}, error) {
+	ptr := reflect.New(typ)
+	opt := ptr.Interface().(optional)
+
+	elem, err := convertNestedScalar(origin, opt.elemType(), layout)
+	if err != nil {
+		return nil, err
+	}
+
+	opt.setValue(elem)
+	return ptr.Elem().Interface(), nil
+}
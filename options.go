@@ -2,19 +2,198 @@ package gmvc
 
 type GmvcOptions struct {
 	autodef Src
+
+	// Auto的查找顺序，为空则使用默认的顺序
+	autoOrder []Src
+
+	// 同一个参数从不同来源取到不同的值时返回400
+	rejectAmbiguous bool
+
+	// 参与内容协商的RenderType，按服务端优先级排序，为空则不协商
+	negotiable []RenderType
+
+	// 默认JSONResponsor的配置
+	json jsonOptions
+
+	// 响应压缩的配置
+	compress compressOptions
+
+	// 包装响应body的Envelope，为空则不包装
+	envelope Envelope
+
+	// 嵌套参数的限制
+	nested nestedOptions
+
+	// 由字段名称生成参数名称的策略，为空则使用字段名称
+	naming NamingStrategy
+
+	// 参数名称取自这些tag，例如json
+	tagNames []string
+
+	// Query、Form、Path、Cookie的参数名称忽略大小写
+	foldCase bool
+
+	// Config来源的配置
+	config ConfigProvider
+}
+
+type nestedOptions struct {
+	depth    int
+	elements int
+}
+
+type compressOptions struct {
+	enabled     bool
+	minSize     int
+	encodings   []string
+	compressors map[string]Compressor
 }
 
-type GmvcOption func(options GmvcOptions)
+type jsonOptions struct {
+	prefix            string
+	indent            string
+	disableHTMLEscape bool
+	marshal           func(v any) ([]byte, error)
+}
+
+type GmvcOption func(options *GmvcOptions)
 
 // DefineAuto
-// 定义Auto的行为，从HTTP协议的哪些地方自动获取参数，例如，Auto=Query|Body，则会自动从Query和Body的地方来获取参数
+// 定义Auto的行为，从HTTP协议的哪些地方自动获取参数，例如，Auto=Query|Body，则会自动从Query和Body的地方来获取参数。
+// 参数的顺序即查找的优先级，例如DefineAuto(PathSrc, QuerySrc, FormSrc)，用'|'组合的来源按照默认的顺序查找。
 func DefineAuto(srclist ...Src) GmvcOption {
-	return func(options GmvcOptions) {
+	return func(options *GmvcOptions) {
 		var auto Src = 0
 		for _, src := range srclist {
 			auto |= src
 		}
 
 		options.autodef = auto
+		options.autoOrder = expandSrc(srclist...)
+	}
+}
+
+// RejectAmbiguous
+// 同一个参数从Header、Query、Path、Form、Cookie中的多个来源取到不同的值时，返回400，而不是按优先级取第一个。
+func RejectAmbiguous() GmvcOption {
+	return func(options *GmvcOptions) {
+		options.rejectAmbiguous = true
+	}
+}
+
+// Negotiate
+// 开启内容协商，Action返回非Response的结果时，根据请求的Accept头，从renders中选择Responsor进行渲染。
+// renders的顺序即服务端的优先级，Accept缺失或者为*/*时使用第一个。
+func Negotiate(renders ...RenderType) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.negotiable = renders
+	}
+}
+
+// JSONIndent
+// 默认的JSONResponsor缩进输出
+func JSONIndent(prefix, indent string) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.json.prefix = prefix
+		options.json.indent = indent
+	}
+}
+
+// JSONEscapeHTML
+// 默认的JSONResponsor是否转义HTML字符，默认转义
+func JSONEscapeHTML(escape bool) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.json.disableHTMLEscape = !escape
+	}
+}
+
+// JSONMarshaler
+// 替换默认的JSONResponsor的序列化方法，例如使用更快的JSON库。
+// 请求Body的解析可以通过RegisterBodyDecoder替换。
+func JSONMarshaler(marshal func(v any) ([]byte, error)) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.json.marshal = marshal
+	}
+}
+
+// Compress
+// 开启响应压缩，根据请求的Accept-Encoding在gzip、deflate以及CompressWith注册的算法中协商，
+// 小于minSize字节的body、已经压缩过的媒体类型不压缩。
+func Compress(minSize int) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.compress.enabled = true
+		options.compress.minSize = minSize
+	}
+}
+
+// CompressWith
+// 注册压缩算法，例如brotli，按注册顺序优先于gzip和deflate，同名时替换默认的实现。
+// 需要同时使用Compress开启压缩。
+func CompressWith(encoding string, compressor Compressor) GmvcOption {
+	return func(options *GmvcOptions) {
+		if options.compress.compressors == nil {
+			options.compress.compressors = make(map[string]Compressor)
+		}
+
+		if _, ok := options.compress.compressors[encoding]; !ok {
+			options.compress.encodings = append(options.compress.encodings, encoding)
+		}
+
+		options.compress.compressors[encoding] = compressor
+	}
+}
+
+// UseEnvelope
+// 使用Envelope包装Action的返回结果以及HandleError的输出，只作用于JSON、XML和MsgPack。
+// Raw的Response以及实现了EnvelopeSkipper的Action不会被包装。
+func UseEnvelope(envelope Envelope) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.envelope = envelope
+	}
+}
+
+// NestedLimit
+// 限制Query、Form中嵌套参数的深度以及元素个数（key的个数），例如 items[0].tags[] 的深度为3，超出限制返回400。
+// 默认深度为5，元素个数为1000。
+func NestedLimit(depth, elements int) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.nested.depth = depth
+		options.nested.elements = elements
+	}
+}
+
+// Naming
+// 由字段名称生成参数名称，例如SnakeCase将UserID命名为user_id，param tag中指定名称的字段不受影响。
+// 不作用于Header和Ctx，Header仍然使用字段名称的标准格式，Ctx的key仍然是字段名称。
+func Naming(strategy NamingStrategy) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.naming = strategy
+	}
+}
+
+// FoldCase
+// Query、Form、Path、Cookie的参数名称忽略大小写，例如user_id匹配User_ID，精确匹配优先。Header的名称总是忽略大小写。
+func FoldCase() GmvcOption {
+	return func(options *GmvcOptions) {
+		options.foldCase = true
+	}
+}
+
+// TagNames
+// param tag中没有指定名称时，依次从tags中取参数名称，例如TagNames("form", "json")，优先于Naming。
+// 忽略omitempty等选项，tag为"-"的字段不从Header、Query、Path、Form、Cookie绑定，使得同一个结构体从Query和JSON Body绑定时一致。
+func TagNames(tags ...string) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.tagNames = tags
+	}
+}
+
+// UseConfig
+// 使用ConfigProvider作为Config来源，例如 param:"db.host,Config"，支持default以及类型转换。
+// 静态的配置在BuildAction时查找一次，DynamicConfig（例如FileConfig）每次请求时查找，支持热加载。
+// 注册的singleton在注册时绑定Config来源的字段。
+func UseConfig(provider ConfigProvider) GmvcOption {
+	return func(options *GmvcOptions) {
+		options.config = provider
 	}
 }
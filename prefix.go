@@ -0,0 +1,100 @@
+package gmvc
+
+import (
+	"reflect"
+	"strings"
+)
+
+// prefixedValues are the values whose keys start with the prefix of the map field, the prefix is trimmed,
+// eg. "X-Meta-Trace: 1" of `param:"Header,prefix=X-Meta-"` is {"Trace": ["1"]}.
+type prefixedValues map[string][]string
+
+// drawOutPrefixed collects the values whose keys start with the prefix of the field, from the first source that has any.
+// Header names are matched case-insensitively.
+func drawOutPrefixed(req HttpRequest, fieldMeta *ParamMeta) (originValue interface{}, src Src, present bool) {
+	sources := []struct {
+		src      Src
+		foldCase bool
+		visit    func(f func(key, value string))
+	}{
+		{src: HeaderSrc, foldCase: true, visit: visitHeader(req.Header())},
+		{src: QuerySrc, visit: req.VisitAllQuery},
+		{src: FormSrc, visit: req.VisitAllPostForm},
+		{src: CookieSrc, visit: req.VisitAllCookie},
+	}
+
+	for _, source := range sources {
+		if !hasSourceTag(fieldMeta.source, source.src) {
+			continue
+		}
+
+		if values, ok := collectPrefixed(fieldMeta.prefix, source.foldCase, source.visit); ok {
+			return values, source.src, true
+		}
+	}
+
+	return nil, 0, false
+}
+
+func collectPrefixed(prefix string, foldCase bool, visit func(f func(key, value string))) (prefixedValues, bool) {
+	values := make(prefixedValues)
+	visit(func(key, value string) {
+		if len(key) <= len(prefix) {
+			return
+		}
+
+		if foldCase && strings.EqualFold(key[:len(prefix)], prefix) || !foldCase && strings.HasPrefix(key, prefix) {
+			values[key[len(prefix):]] = append(values[key[len(prefix):]], value)
+		}
+	})
+
+	return values, len(values) > 0
+}
+
+// visitHeader adapts Header.VisitAll to the visitor of strings.
+func visitHeader(header Header) func(f func(key, value string)) {
+	return func(f func(key, value string)) {
+		header.VisitAll(func(k, v []byte) {
+			f(string(k), string(v))
+		})
+	}
+}
+
+// isPrefixType reports whether the type can be bound by prefix, that is map whose key is string,
+// and value is convertible or a slice of convertible, eg. map[string]string and map[string][]string.
+func isPrefixType(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Map || typ.Key().Kind() != reflect.String {
+		return false
+	}
+
+	return isConvertible(typ.Elem())
+}
+
+// convertPrefixed converts the values to the map type of the field.
+func convertPrefixed(fieldMeta *ParamMeta, values prefixedValues) (interface{}, error) {
+	typ := fieldMeta.fieldType.Type
+	elem := typ.Elem()
+	multiValued := elem.Kind() == reflect.Slice && !hasConverter(elem) && elem.Elem().Kind() != reflect.Uint8
+
+	ret := reflect.MakeMapWithSize(typ, len(values))
+	for key, value := range values {
+		var (
+			v   interface{}
+			err error
+		)
+
+		if multiValued {
+			v, err = convertSlice(elem, fieldMeta.sep, fieldMeta.layout, value)
+		} else {
+			v, err = convert(value[0], elem, fieldMeta.layout)
+		}
+
+		if err != nil {
+			return nil, &FieldError{Field: fieldMeta.prefix + key, Value: value[0], Err: err}
+		}
+
+		ret.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), reflect.ValueOf(v).Convert(elem))
+	}
+
+	return ret.Interface(), nil
+}
@@ -0,0 +1,226 @@
+package gmvc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ProblemDetails is the RFC 9457 problem details object, rendered as `application/problem+json`.
+type ProblemDetails struct {
+	// Type is a URI reference identifying the problem type, "about:blank" by default.
+	Type string `json:"type,omitempty"`
+
+	// Title is a short summary of the problem type.
+	Title string `json:"title,omitempty"`
+
+	// Status is the HTTP status code.
+	Status int `json:"status,omitempty"`
+
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Errors lists the per-field binding or validation failures.
+	Errors []*FieldError `json:"errors,omitempty"`
+
+	// Extensions are extra members rendered along with the standard ones.
+	// An extension never overrides a standard member.
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON flattens the extension members into the problem object.
+func (p *ProblemDetails) MarshalJSON() ([]byte, error) {
+	type standard ProblemDetails
+	b, err := json.Marshal((*standard)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+
+	members := make(map[string]json.RawMessage, len(p.Extensions))
+	for k, v := range p.Extensions {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		members[k] = raw
+	}
+
+	standards := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(b, &standards); err != nil {
+		return nil, err
+	}
+
+	for k, v := range standards {
+		members[k] = v
+	}
+
+	return json.Marshal(members)
+}
+
+// ProblemType describes a kind of problem mapped from errors.
+type ProblemType struct {
+	// Type is the URI identifying the problem type.
+	Type string
+
+	// Title is the short summary of the problem type.
+	Title string
+
+	// Status overrides the HTTP status, 0 means the one from [AsHTTPError] is kept.
+	Status int
+
+	// Code overrides the "code" extension. If it's empty and Status overrides the status,
+	// the code derived from the status, eg. "conflict", replaces the one derived from the original status.
+	Code string
+}
+
+// ProblemRegistry maps Go errors to problem types.
+// Sentinel errors are matched by [errors.Is], error types by [errors.As], in the order of registration.
+type ProblemRegistry struct {
+	matchers []func(err error) (ProblemType, bool)
+}
+
+// NewProblemRegistry creates an empty [ProblemRegistry].
+func NewProblemRegistry() *ProblemRegistry {
+	return &ProblemRegistry{}
+}
+
+// Register maps the sentinel error to the problem type.
+func (r *ProblemRegistry) Register(target error, pt ProblemType) *ProblemRegistry {
+	r.matchers = append(r.matchers, func(err error) (ProblemType, bool) {
+		return pt, errors.Is(err, target)
+	})
+
+	return r
+}
+
+// RegisterProblemType maps the error type E to the problem type.
+func RegisterProblemType[E error](r *ProblemRegistry, pt ProblemType) *ProblemRegistry {
+	r.matchers = append(r.matchers, func(err error) (ProblemType, bool) {
+		var target E
+		return pt, errors.As(err, &target)
+	})
+
+	return r
+}
+
+// Lookup finds the problem type of the error.
+func (r *ProblemRegistry) Lookup(err error) (ProblemType, bool) {
+	for _, match := range r.matchers {
+		if pt, ok := match(err); ok {
+			return pt, true
+		}
+	}
+
+	return ProblemType{}, false
+}
+
+// Problem builds the problem details of the error.
+func (r *ProblemRegistry) Problem(ctx GmvcContext, err error) *ProblemDetails {
+	httpErr := AsHTTPError(err)
+
+	problem := &ProblemDetails{
+		Type:   "about:blank",
+		Status: httpErr.Status,
+	}
+
+	code := httpErr.Code
+	if pt, ok := r.Lookup(err); ok {
+		if pt.Type != "" {
+			problem.Type = pt.Type
+		}
+
+		if pt.Title != "" {
+			problem.Title = pt.Title
+		}
+
+		if pt.Status != 0 {
+			problem.Status = pt.Status
+		}
+
+		// 默认的code取自最终的status，自定义的code保留
+		if pt.Code != "" {
+			code = pt.Code
+		} else if code == statusCode(httpErr.Status) {
+			code = statusCode(problem.Status)
+		}
+	}
+
+	// 默认的title取自最终的status
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+
+	// 默认的message（status的文本）不作为detail
+	if httpErr.Message != problem.Title && httpErr.Message != http.StatusText(httpErr.Status) {
+		problem.Detail = httpErr.Message
+	}
+
+	if u := ctx.HttpRequest().URL(); u != nil {
+		problem.Instance = u.Path
+	}
+
+	if fields, ok := httpErr.Details.([]*FieldError); ok {
+		problem.Errors = fields
+	} else if httpErr.Details != nil {
+		problem.Extensions = map[string]any{"details": httpErr.Details}
+	}
+
+	if problem.Extensions == nil {
+		problem.Extensions = make(map[string]any, 1)
+	}
+	problem.Extensions["code"] = code
+
+	return problem
+}
+
+// ProblemErrorHandler creates a [HandleError] rendering errors as `application/problem+json`.
+// The registry can be nil, then the problems are all "about:blank".
+func ProblemErrorHandler(registry *ProblemRegistry) HandleError {
+	if registry == nil {
+		registry = NewProblemRegistry()
+	}
+
+	return func(ctx GmvcContext, err error) interface{} {
+		problem := registry.Problem(ctx, err)
+		if problem.Status >= http.StatusInternalServerError {
+			logServerError(ctx, err)
+		}
+
+		return &Response{
+			StatusCode: problem.Status,
+			Render:     Problem,
+			Body:       problem,
+		}
+	}
+}
+
+var _ Responsor = (*ProblemResponsor)(nil)
+
+// ProblemResponsor problem+json实现
+type ProblemResponsor struct{}
+
+// Response 返回的方法
+func (r *ProblemResponsor) Response(ctx GmvcContext, resp *Response) {
+	setDefault(resp)
+	setHeader(ctx, resp)
+
+	b, err := json.Marshal(resp.Body)
+	if err != nil {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.HttpResponse().SetHeader("Content-Type", "application/problem+json")
+	ctx.HttpResponse().Status(resp.StatusCode)
+	ctx.HttpResponse().Body(bytes.NewReader(b))
+}
+
+// MediaTypes 协商用的媒体类型
+func (r *ProblemResponsor) MediaTypes() []string {
+	return []string{"application/problem+json"}
+}
@@ -0,0 +1,99 @@
+package gmvc
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errOutOfCredit = errors.New("out of credit")
+	errDuplicated  = errors.New("duplicated")
+)
+
+type quotaError struct {
+	Remain int
+}
+
+func (e *quotaError) Error() string { return "quota exceeded" }
+
+type problemAction struct {
+	Age  int    `param:"Query" checker:"positive"`
+	Kind string `param:"Query"`
+}
+
+func (a *problemAction) Go() (interface{}, error) {
+	switch a.Kind {
+	case "credit":
+		return nil, Forbidden("your balance is 30").WithCause(errOutOfCredit)
+	case "quota":
+		return nil, &quotaError{Remain: 0}
+	case "duplicated":
+		return nil, errDuplicated
+	}
+
+	return a.Age, nil
+}
+
+func TestProblemErrorHandler(t *testing.T) {
+	registry := NewProblemRegistry().
+		Register(errOutOfCredit, ProblemType{Type: "https://example.com/probs/out-of-credit", Title: "You do not have enough credit."}).
+		Register(errDuplicated, ProblemType{Status: http.StatusConflict})
+	RegisterProblemType[*quotaError](registry, ProblemType{Type: "https://example.com/probs/quota", Title: "Quota exceeded.", Status: http.StatusTooManyRequests, Code: "quota_exceeded"})
+
+	builder := CreateGmvcBuilder().
+		SetErrorHandler(ProblemErrorHandler(registry)).
+		RegisterValidator("positive", func(ctx GmvcContext, fieldMeta *ParamMeta, value interface{}) error {
+			if v, _ := value.(int); v <= 0 {
+				return errors.New("must be positive")
+			}
+
+			return nil
+		})
+
+	ctx := newMockContext(http.MethodGet, "/account/12345?Kind=credit&Age=1")
+	serve(builder, &problemAction{}, ctx)
+	assert.Equal(t, http.StatusForbidden, ctx.resp.status)
+	contentType, _ := ctx.resp.header.Get("Content-Type")
+	assert.Equal(t, "application/problem+json", contentType)
+	assert.JSONEq(t, `{
+		"type": "https://example.com/probs/out-of-credit",
+		"title": "You do not have enough credit.",
+		"status": 403,
+		"detail": "your balance is 30",
+		"instance": "/account/12345",
+		"code": "forbidden"
+	}`, bodyString(ctx))
+
+	ctx = newMockContext(http.MethodGet, "/?Kind=quota&Age=1")
+	serve(builder, &problemAction{}, ctx)
+	assert.Equal(t, http.StatusTooManyRequests, ctx.resp.status)
+	assert.Contains(t, bodyString(ctx), `"code":"quota_exceeded"`)
+
+	// the default title and code follow the status of the problem type
+	ctx = newMockContext(http.MethodGet, "/?Kind=duplicated&Age=1")
+	serve(builder, &problemAction{}, ctx)
+	assert.Equal(t, http.StatusConflict, ctx.resp.status)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Conflict",
+		"status": 409,
+		"instance": "/",
+		"code": "conflict"
+	}`, bodyString(ctx))
+
+	ctx = newMockContext(http.MethodGet, "/?Age=-1")
+	serve(builder, &problemAction{}, ctx)
+	assert.Equal(t, http.StatusBadRequest, ctx.resp.status)
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "validation failed",
+		"instance": "/",
+		"code": "validation_failed",
+		"errors": [{"field": "Age", "message": "must be positive"}]
+	}`, bodyString(ctx))
+}
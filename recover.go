@@ -0,0 +1,62 @@
+package gmvc
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is the error passed to [HandleError] when an Action or a Middleware panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recovery handles the panic caught in the handlerfunc.
+// The panic is logged with its stack, then converted to a response by [RecoverFunc] if registered,
+// or else by [HandleError] with a [PanicError].
+// If the response has been committed when panicking, nothing more is written.
+// If the handling panics again, eg. in a custom error handler or responsor, a bare 500 is answered.
+func (gmvc *GmvcBuilder) recovery(ctx GmvcContext, x interface{}) {
+	perr := &PanicError{Value: x, Stack: debug.Stack()}
+	logError(ctx, "gmvc: action %s panic: %v\n%s", actionName(ctx), x, perr.Stack)
+
+	if ctx.HttpResponse().Committed() {
+		return
+	}
+
+	// 处理panic时再次panic，不能再交给用户的handler
+	defer func() {
+		if x := recover(); x != nil {
+			logError(ctx, "gmvc: action %s panic while handling the panic: %v\n%s", actionName(ctx), x, debug.Stack())
+			if !ctx.HttpResponse().Committed() {
+				ctx.HttpResponse().Status(http.StatusInternalServerError)
+			}
+		}
+	}()
+
+	var ret interface{}
+	var err error = perr
+	if gmvc.recover != nil {
+		ret, err = gmvc.recover(ctx, x)
+	}
+
+	if err != nil {
+		ret = gmvc.handleError(ctx, err)
+	}
+
+	gmvc.doResponse(ctx, ret)
+}
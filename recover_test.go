@@ -0,0 +1,91 @@
+package gmvc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger struct {
+	errors []string
+}
+
+func (l *recordLogger) Debug(ctx context.Context, msg string, vars ...interface{}) {}
+func (l *recordLogger) Info(ctx context.Context, msg string, vars ...interface{})  {}
+func (l *recordLogger) Error(ctx context.Context, msg string, vars ...interface{}) {
+	l.errors = append(l.errors, fmt.Sprintf(msg, vars...))
+}
+
+type panicAction struct{}
+
+func (a *panicAction) Go() (interface{}, error) {
+	panic("boom")
+}
+
+type panicResponsor struct{}
+
+func (r *panicResponsor) Response(ctx GmvcContext, resp *Response) {
+	ctx.HttpResponse().Status(http.StatusOK)
+	ctx.HttpResponse().Body(strings.NewReader("partial"))
+	panic("broken pipe")
+}
+
+func (r *panicResponsor) MediaTypes() []string {
+	return nil
+}
+
+type panicRenderAction struct{}
+
+func (a *panicRenderAction) Go() (interface{}, error) {
+	return &Response{Render: 99, Body: "x"}, nil
+}
+
+func TestRecovery(t *testing.T) {
+	log := &recordLogger{}
+	SetLogger(log)
+	defer SetLogger(nil)
+
+	var handled *PanicError
+	builder := CreateGmvcBuilder()
+	builder.SetErrorHandler(func(ctx GmvcContext, err error) interface{} {
+		handled, _ = err.(*PanicError)
+		return DefaultErrorHandler(ctx, err)
+	})
+
+	ctx := newMockContext(http.MethodGet, "/")
+	serve(builder, &panicAction{}, ctx)
+	assert.Equal(t, http.StatusInternalServerError, ctx.resp.status)
+	assert.JSONEq(t, `{"code":"internal_server_error","message":"Internal Server Error"}`, bodyString(ctx))
+	assert.Equal(t, "boom", handled.Value)
+	assert.Contains(t, string(handled.Stack), "panicAction")
+	assert.Len(t, log.errors, 1)
+	assert.Contains(t, log.errors[0], "panic: boom")
+
+	// panic after the response has started must not respond again
+	builder.RegisterResponsor(99, &panicResponsor{})
+	ctx = newMockContext(http.MethodGet, "/")
+	serve(builder, &panicRenderAction{}, ctx)
+	assert.Equal(t, http.StatusOK, ctx.resp.status)
+	assert.Equal(t, "partial", bodyString(ctx))
+}
+
+func TestRecoveryPanicInErrorHandler(t *testing.T) {
+	log := &recordLogger{}
+	SetLogger(log)
+	defer SetLogger(nil)
+
+	builder := CreateGmvcBuilder()
+	builder.SetErrorHandler(func(ctx GmvcContext, err error) interface{} {
+		panic("handler boom")
+	})
+
+	ctx := newMockContext(http.MethodGet, "/")
+	assert.NotPanics(t, func() { serve(builder, &panicAction{}, ctx) })
+	assert.Equal(t, http.StatusInternalServerError, ctx.resp.status)
+	assert.Len(t, log.errors, 2)
+	assert.Contains(t, log.errors[1], "handler boom")
+}
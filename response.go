@@ -3,6 +3,7 @@ package gmvc
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 )
@@ -19,6 +20,27 @@ const (
 
 	// String: content-type="text/plain"
 	String RenderType = 2
+
+	// Problem: content-type="application/problem+json"
+	Problem RenderType = 3
+
+	// XML: content-type="application/xml"
+	XML RenderType = 4
+
+	// MsgPack: content-type="application/msgpack"
+	MsgPack RenderType = 5
+
+	// Protobuf: content-type="application/x-protobuf", Body must be proto.Message
+	Protobuf RenderType = 6
+
+	// Streaming: content-type is decided by the [Stream], Body must be *Stream
+	Streaming RenderType = 7
+
+	// SSE: content-type="text/event-stream", Body must be *SSEResponse
+	SSE RenderType = 8
+
+	// File: content-type is detected from the file, Body must be *FileResponse
+	File RenderType = 9
 )
 
 // Response is the convenient struct to return HTTP response.
@@ -29,22 +51,53 @@ type Response struct {
 	Render     RenderType
 	Header     map[string]string
 	Model      map[string]interface{}
+
+	// Cookies are set by `Set-Cookie` headers.
+	Cookies []*http.Cookie
+
+	// Raw bypasses the Envelope, the Body is rendered as is.
+	Raw bool
 }
 
 // Responsor 负责返回，可能会有多种Render
 type Responsor interface {
 	Response(ctx GmvcContext, resp *Response)
+
+	// MediaTypes returns the media types the Responsor produces, used by content negotiation.
+	MediaTypes() []string
+}
+
+// fallibleResponsor is implemented by the Responsors encoding the body before anything is written,
+// so that the encoding error is answered by [HandleError] instead of an empty 500.
+type fallibleResponsor interface {
+	render(ctx GmvcContext, resp *Response) error
+}
+
+// selectiveResponsor is implemented by the Responsors which can't encode every body, eg. XML can't encode maps.
+// Such bodies are never negotiated to them.
+type selectiveResponsor interface {
+	accepts(body any) bool
 }
 
 // JSONResponsor Json实现
-type JSONResponsor struct{}
+type JSONResponsor struct {
+	// Prefix and Indent indent the JSON, see [json.MarshalIndent].
+	Prefix string
+	Indent string
+
+	// DisableHTMLEscape keeps <, > and & as they are, instead of escaping them to \u003c, \u003e and \u0026.
+	DisableHTMLEscape bool
+
+	// Marshal replaces encoding/json, eg. by a faster JSON library. Prefix, Indent and DisableHTMLEscape are ignored.
+	Marshal func(v any) ([]byte, error)
+}
 
 // Response 返回的方法
 func (r *JSONResponsor) Response(ctx GmvcContext, resp *Response) {
 	setDefault(resp)
 	setHeader(ctx, resp)
 
-	b, err := json.Marshal(resp.Body)
+	b, err := r.marshal(resp.Body)
 	if err != nil {
 		ctx.HttpResponse().Status(http.StatusInternalServerError)
 		return
@@ -55,7 +108,33 @@ func (r *JSONResponsor) Response(ctx GmvcContext, resp *Response) {
 	ctx.HttpResponse().Body(bytes.NewReader(b))
 }
 
-// HTMLResponsor html实现
+// MediaTypes 协商用的媒体类型
+func (r *JSONResponsor) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+func (r *JSONResponsor) marshal(v any) ([]byte, error) {
+	if r.Marshal != nil {
+		return r.Marshal(v)
+	}
+
+	if r.Prefix == "" && r.Indent == "" && !r.DisableHTMLEscape {
+		return json.Marshal(v)
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetIndent(r.Prefix, r.Indent)
+	encoder.SetEscapeHTML(!r.DisableHTMLEscape)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	// Encoder总是以换行结尾，和json.Marshal保持一致
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// HTMLResponsor html实现，委托给底层框架渲染，框架无关的实现见TemplateResponsor
 type HTMLResponsor struct{}
 
 // Response 返回的方法
@@ -65,6 +144,11 @@ func (r *HTMLResponsor) Response(ctx GmvcContext, resp *Response) {
 	ctx.HttpResponse().HTML(resp.StatusCode, resp.Body.(string), resp.Model)
 }
 
+// MediaTypes 协商用的媒体类型
+func (r *HTMLResponsor) MediaTypes() []string {
+	return []string{"text/html"}
+}
+
 // StringResponsor string实现
 type StringResponsor struct{}
 
@@ -75,7 +159,17 @@ func (r *StringResponsor) Response(ctx GmvcContext, resp *Response) {
 
 	ctx.HttpResponse().SetHeader("Content-Type", "text/plain")
 	ctx.HttpResponse().Status(resp.StatusCode)
-	ctx.HttpResponse().Body(strings.NewReader(resp.Body.(string)))
+	body, ok := resp.Body.(string)
+	if !ok && resp.Body != nil {
+		body = fmt.Sprint(resp.Body)
+	}
+
+	ctx.HttpResponse().Body(strings.NewReader(body))
+}
+
+// MediaTypes 协商用的媒体类型
+func (r *StringResponsor) MediaTypes() []string {
+	return []string{"text/plain"}
 }
 
 var _ Responsor = (*JSONResponsor)(nil)
@@ -98,4 +192,8 @@ func setHeader(ctx GmvcContext, resp *Response) {
 			ctx.HttpResponse().SetHeader(k, v)
 		}
 	}
+
+	for _, cookie := range resp.Cookies {
+		ctx.HttpResponse().SetCookie(cookie)
+	}
 }
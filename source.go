@@ -0,0 +1,158 @@
+package gmvc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrAmbiguousParameter means the parameter arrives from two sources with different values, see [RejectAmbiguous].
+var ErrAmbiguousParameter = errors.New("ambiguous parameter")
+
+// drawOrder 参数来源默认的查找顺序
+var drawOrder = []Src{HeaderSrc, QuerySrc, PathSrc, FormSrc, CookieSrc, BodySrc, CtxSrc, PrincipalSrc, ConfigSrc}
+
+var srcNames = map[Src]string{
+	HeaderSrc:    XHeader,
+	QuerySrc:     XQuery,
+	PathSrc:      XPath,
+	FormSrc:      XForm,
+	CookieSrc:    XCookie,
+	BodySrc:      XBody,
+	CtxSrc:       XCtx,
+	PrincipalSrc: XPrincipal,
+	ConfigSrc:    XConfig,
+	DefaultSrc:   XDefault,
+}
+
+func (s Src) String() string {
+	if name, ok := srcNames[s]; ok {
+		return name
+	}
+
+	var names []string
+	for _, src := range drawOrder {
+		if hasSourceTag(s, src) {
+			names = append(names, srcNames[src])
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+// expandSrc expands the sources combined by '|' in the default order, eg. QuerySrc|HeaderSrc into [HeaderSrc, QuerySrc].
+func expandSrc(srclist ...Src) []Src {
+	var ret []Src
+	var seen Src
+	for _, src := range srclist {
+		for _, one := range drawOrder {
+			if hasSourceTag(src, one) && !hasSourceTag(seen, one) {
+				seen |= one
+				ret = append(ret, one)
+			}
+		}
+	}
+
+	return ret
+}
+
+// parseAuto parses the sources of "Auto(Path,Query)" in order, it returns false if the value is not in this form.
+func parseAuto(value string) ([]Src, bool) {
+	list, ok := cutPrefix(value, XAuto+"(")
+	if !ok || !strings.HasSuffix(list, ")") {
+		return nil, false
+	}
+
+	var ret []Src
+	for _, name := range strings.Split(strings.TrimSuffix(list, ")"), XSplit) {
+		name = strings.TrimSpace(name)
+		found := false
+		for src, srcName := range srcNames {
+			if srcName == name && src != DefaultSrc {
+				ret = append(ret, src)
+				found = true
+			}
+		}
+
+		if !found {
+			panic(fmt.Sprintf("gmvc: unknown source %q in %q", name, value))
+		}
+	}
+
+	return ret, true
+}
+
+// sourceOrder returns the order to look up the sources of the field, the sources of Auto first in the order of Auto,
+// then the others in the default order.
+func sourceOrder(source Src, auto []Src) []Src {
+	var ret []Src
+	var seen Src
+	for _, list := range [][]Src{auto, drawOrder} {
+		for _, src := range list {
+			if hasSourceTag(source, src) && !hasSourceTag(seen, src) {
+				seen |= src
+				ret = append(ret, src)
+			}
+		}
+	}
+
+	return ret
+}
+
+// splitParam splits the param tag by ',', except those in parentheses, eg. "id,Auto(Path,Query)" into ["id", "Auto(Path,Query)"].
+func splitParam(tag string) []string {
+	var ret []string
+	depth, start := 0, 0
+	for i, c := range tag {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				ret = append(ret, tag[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(ret, tag[start:])
+}
+
+// checkAmbiguous returns the FieldError if the parameter found from the source also arrives from the following sources
+// with a different value. Only the sources read from the HTTP request are compared.
+func (instance *GmvcBuilder) checkAmbiguous(ctx GmvcContext, fieldMeta *ParamMeta, src Src, originValue interface{}) *FieldError {
+	after := false
+	for _, other := range fieldMeta.order {
+		if other == src {
+			after = true
+			continue
+		}
+
+		if !after || other&(HeaderSrc|QuerySrc|PathSrc|FormSrc|CookieSrc) == 0 {
+			continue
+		}
+
+		value, ok := instance.drawOutSource(ctx, fieldMeta, other)
+		if ok && !reflect.DeepEqual(normalizeOrigin(value), normalizeOrigin(originValue)) {
+			return &FieldError{
+				Field: fieldMeta.fieldName,
+				Value: fmt.Sprint(normalizeOrigin(originValue)),
+				Err:   fmt.Errorf("%w: %s and %s have different values", ErrAmbiguousParameter, src, other),
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeOrigin makes the values of nested parameters comparable regardless of the matched name.
+func normalizeOrigin(value interface{}) interface{} {
+	if nested, ok := value.(nestedValues); ok {
+		return nested.pairs
+	}
+
+	return value
+}
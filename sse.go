@@ -0,0 +1,245 @@
+package gmvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSEEvent is a message of Server-Sent Events.
+type SSEEvent struct {
+	// ID sets the event ID, the client sends it back by the `Last-Event-ID` header on reconnection.
+	ID string
+
+	// Event is the event type, "message" is used by the client if empty.
+	Event string
+
+	// Data is the payload, string and []byte are written as is, others are marshaled as JSON.
+	Data interface{}
+
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// SSEResponse is returned from [Action.Go] to push Server-Sent Events (text/event-stream).
+// Either Events or Source must be set.
+// Each event is flushed to the client immediately, the stream ends when the events run out,
+// the client goes away or the [GmvcContext] is done.
+type SSEResponse struct {
+	// Events are pushed until the channel is closed, nil events are skipped.
+	// The producer should stop sending when the request context is done, as the stream stops receiving then.
+	Events <-chan *SSEEvent
+
+	// Source pushes events by send until it returns.
+	// lastEventID is the `Last-Event-ID` header of a reconnecting client, the source should resume after it.
+	// send fails once the client goes away, Source should return then.
+	Source func(ctx context.Context, lastEventID string, send func(*SSEEvent) error) error
+
+	// Retry is sent before any event, telling the client how long to wait before reconnecting.
+	Retry time.Duration
+
+	// Heartbeat sends a comment periodically to keep idle connections alive, disabled if zero.
+	Heartbeat time.Duration
+
+	// Header is merged into the response header.
+	Header map[string]string
+}
+
+// LastEventID returns the `Last-Event-ID` header sent by a reconnecting EventSource client.
+func LastEventID(ctx GmvcContext) string {
+	id, _ := ctx.HttpRequest().Header().Get("Last-Event-ID")
+	return id
+}
+
+var _ Responsor = (*SSEResponsor)(nil)
+
+// SSEResponsor Server-Sent Events实现，Response.Body必须为*SSEResponse
+type SSEResponsor struct{}
+
+// Response 返回的方法
+func (r *SSEResponsor) Response(ctx GmvcContext, resp *Response) {
+	sse, ok := resp.Body.(*SSEResponse)
+	if !ok || (sse.Events == nil && sse.Source == nil) {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	setDefault(resp)
+	setHeader(ctx, resp)
+
+	ctx.HttpResponse().SetHeader("Content-Type", "text/event-stream")
+	ctx.HttpResponse().SetHeader("Cache-Control", "no-cache")
+	ctx.HttpResponse().SetHeader("X-Accel-Buffering", "no")
+	ctx.HttpResponse().Status(resp.StatusCode)
+
+	stream := &sseWriter{out: ctx.HttpResponse().Writer()}
+
+	// 先发送header，客户端才能确认连接已经建立
+	if err := stream.write(&SSEEvent{Retry: sse.Retry}); err != nil {
+		logInfo(ctx, "gmvc: action %s event stream closed: %v", actionName(ctx), err)
+		return
+	}
+
+	// heartbeat必须在返回前停止，之后底层框架会结束response
+	if sse.Heartbeat > 0 {
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		defer func() {
+			close(done)
+			wg.Wait()
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream.heartbeat(sse.Heartbeat, done)
+		}()
+	}
+
+	var err error
+	if sse.Source != nil {
+		err = sse.Source(ctx, LastEventID(ctx), stream.write)
+	} else {
+		err = stream.drain(ctx, sse.Events)
+	}
+
+	if err != nil {
+		logInfo(ctx, "gmvc: action %s event stream closed: %v", actionName(ctx), err)
+	}
+}
+
+// MediaTypes Server-Sent Events不参与协商
+func (r *SSEResponsor) MediaTypes() []string {
+	return nil
+}
+
+// sseResponse converts the SSEResponse to a Response rendered by [SSEResponsor].
+func sseResponse(sse *SSEResponse) *Response {
+	return &Response{
+		Header: sse.Header,
+		Render: SSE,
+		Body:   sse,
+	}
+}
+
+// sseWriter serializes the events and the heartbeats to the client.
+type sseWriter struct {
+	mu  sync.Mutex
+	out ResponseWriter
+
+	// err is the first write error, the client is gone after that.
+	err error
+}
+
+func (w *sseWriter) drain(ctx context.Context, events <-chan *SSEEvent) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			if err := w.write(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *sseWriter) heartbeat(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if w.send([]byte(": heartbeat\n\n")) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (w *sseWriter) write(event *SSEEvent) error {
+	// nil事件直接跳过，response已经提交，不能panic
+	if event == nil {
+		return nil
+	}
+
+	b, err := encodeSSEEvent(event)
+	if err != nil {
+		return err
+	}
+
+	return w.send(b)
+}
+
+func (w *sseWriter) send(b []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+
+	if _, w.err = w.out.Write(b); w.err == nil {
+		w.err = w.out.Flush()
+	}
+
+	return w.err
+}
+
+// encodeSSEEvent encodes the event in the text/event-stream format, multi-line data is split into data fields.
+func encodeSSEEvent(event *SSEEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	if event.ID != "" {
+		buf.WriteString("id: " + sseField(event.ID) + "\n")
+	}
+
+	if event.Event != "" {
+		buf.WriteString("event: " + sseField(event.Event) + "\n")
+	}
+
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	if event.Data != nil {
+		var data string
+		switch v := event.Data.(type) {
+		case string:
+			data = v
+		case []byte:
+			data = string(v)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+
+			data = string(b)
+		}
+
+		data = strings.ReplaceAll(data, "\r\n", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			buf.WriteString("data: " + line + "\n")
+		}
+	}
+
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// sseField strips line breaks which would end the field.
+func sseField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
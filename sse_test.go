@@ -0,0 +1,104 @@
+package gmvc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sseAction struct {
+	Kind string `param:"kind,Query"`
+}
+
+func (a *sseAction) Go() (interface{}, error) {
+	switch a.Kind {
+	case "chan":
+		ch := make(chan *SSEEvent, 3)
+		ch <- &SSEEvent{ID: "1", Event: "progress", Data: map[string]int{"percent": 50}}
+		ch <- nil
+		ch <- &SSEEvent{ID: "2", Data: "line1\nline2"}
+		close(ch)
+
+		return &SSEResponse{Events: ch, Retry: 3 * time.Second}, nil
+	case "resume":
+		return &SSEResponse{
+			Source: func(ctx context.Context, lastEventID string, send func(*SSEEvent) error) error {
+				if err := send(nil); err != nil {
+					return err
+				}
+
+				return send(&SSEEvent{ID: lastEventID + "+1", Data: "resumed"})
+			},
+		}, nil
+	case "heartbeat":
+		return &SSEResponse{
+			Heartbeat: 5 * time.Millisecond,
+			Source: func(ctx context.Context, lastEventID string, send func(*SSEEvent) error) error {
+				time.Sleep(30 * time.Millisecond)
+				return nil
+			},
+		}, nil
+	case "cancel":
+		// never closed, the stream stops by the context
+		return &SSEResponse{Events: make(chan *SSEEvent)}, nil
+	}
+
+	return nil, nil
+}
+
+func TestSSE(t *testing.T) {
+	builder := CreateGmvcBuilder()
+	ctx := newMockContext(http.MethodGet, "/events?kind=chan")
+	serve(builder, &sseAction{}, ctx)
+
+	contentType, _ := ctx.resp.header.Get("Content-Type")
+	assert.Equal(t, "text/event-stream", contentType)
+	assert.Equal(t, "retry: 3000\n\n"+
+		"id: 1\nevent: progress\ndata: {\"percent\":50}\n\n"+
+		"id: 2\ndata: line1\ndata: line2\n\n", ctx.resp.body.String())
+
+	// flushed per event, nil events are skipped
+	assert.Equal(t, 3, ctx.resp.flushes)
+}
+
+func TestSSELastEventID(t *testing.T) {
+	builder := CreateGmvcBuilder()
+	ctx := newMockContext(http.MethodGet, "/events?kind=resume")
+	ctx.req.header.set("Last-Event-ID", "41")
+	serve(builder, &sseAction{}, ctx)
+
+	assert.Equal(t, "\nid: 41+1\ndata: resumed\n\n", ctx.resp.body.String())
+}
+
+func TestSSEHeartbeat(t *testing.T) {
+	builder := CreateGmvcBuilder()
+	ctx := newMockContext(http.MethodGet, "/events?kind=heartbeat")
+	serve(builder, &sseAction{}, ctx)
+
+	assert.True(t, strings.Contains(ctx.resp.body.String(), ": heartbeat\n\n"))
+}
+
+func TestSSECancel(t *testing.T) {
+	builder := CreateGmvcBuilder()
+	ctx := newMockContext(http.MethodGet, "/events?kind=cancel")
+
+	c, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	ctx.Context = c
+
+	done := make(chan struct{})
+	go func() {
+		serve(builder, &sseAction{}, ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("event stream is not stopped by the context")
+	}
+}
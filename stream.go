@@ -0,0 +1,177 @@
+package gmvc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Stream is returned from [Action.Go] to stream the response body with chunked transfer,
+// instead of buffering the whole body in memory.
+// Either Reader or Writer must be set.
+type Stream struct {
+	// ContentType of the response, "application/octet-stream" by default.
+	ContentType string
+
+	// StatusCode of the response, 200 by default.
+	StatusCode int
+
+	// Header is merged into the response header.
+	Header map[string]string
+
+	// Reader is copied to the response body, it is closed after copying if it is an io.Closer.
+	Reader io.Reader
+
+	// Writer writes the response body on a separate goroutine.
+	// If it returns an error, the response is aborted so that the client can notice the truncated body.
+	Writer func(w io.Writer) error
+}
+
+// StreamReader creates a [Stream] copying r to the response body.
+func StreamReader(contentType string, r io.Reader) *Stream {
+	return &Stream{ContentType: contentType, Reader: r}
+}
+
+// StreamWriter creates a [Stream] whose body is written by fn.
+func StreamWriter(contentType string, fn func(w io.Writer) error) *Stream {
+	return &Stream{ContentType: contentType, Writer: fn}
+}
+
+// StreamNDJSON creates a [Stream] writing each item received from ch as a line of JSON (application/x-ndjson),
+// until ch is closed.
+// The producer should stop sending when the request context is done, as the stream stops receiving then.
+func StreamNDJSON[T any](ch <-chan T) *Stream {
+	return StreamNDJSONSeq(chanSeq(ch))
+}
+
+// StreamNDJSONSeq creates a [Stream] writing each item yielded by seq as a line of JSON (application/x-ndjson).
+func StreamNDJSONSeq[T any](seq func(yield func(T) bool)) *Stream {
+	return StreamWriter("application/x-ndjson", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+
+		var err error
+		seq(func(item T) bool {
+			err = encoder.Encode(item)
+			return err == nil
+		})
+
+		return err
+	})
+}
+
+// StreamJSONArray creates a [Stream] writing the items received from ch as a JSON array, until ch is closed.
+// The producer should stop sending when the request context is done, as the stream stops receiving then.
+func StreamJSONArray[T any](ch <-chan T) *Stream {
+	return StreamJSONArraySeq(chanSeq(ch))
+}
+
+// StreamJSONArraySeq creates a [Stream] writing the items yielded by seq as a JSON array.
+func StreamJSONArraySeq[T any](seq func(yield func(T) bool)) *Stream {
+	return StreamWriter("application/json", func(w io.Writer) error {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+
+		var err error
+		first := true
+		seq(func(item T) bool {
+			var b []byte
+			if b, err = json.Marshal(item); err != nil {
+				return false
+			}
+
+			if !first {
+				if _, err = io.WriteString(w, ","); err != nil {
+					return false
+				}
+			}
+			first = false
+
+			_, err = w.Write(b)
+			return err == nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(w, "]")
+		return err
+	})
+}
+
+func chanSeq[T any](ch <-chan T) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for item := range ch {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+var _ Responsor = (*StreamResponsor)(nil)
+
+// StreamResponsor stream实现，Response.Body必须为*Stream
+type StreamResponsor struct{}
+
+// Response 返回的方法
+func (r *StreamResponsor) Response(ctx GmvcContext, resp *Response) {
+	stream, ok := resp.Body.(*Stream)
+	if !ok || (stream.Reader == nil && stream.Writer == nil) {
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	setDefault(resp)
+	setHeader(ctx, resp)
+
+	contentType := stream.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	ctx.HttpResponse().SetHeader("Content-Type", contentType)
+	ctx.HttpResponse().Status(resp.StatusCode)
+
+	if stream.Reader != nil {
+		ctx.HttpResponse().Body(stream.Reader)
+		return
+	}
+
+	// Writer在单独的goroutine中写入，底层框架从pipe中读取。
+	// 底层框架关闭body（例如客户端断开）时，Writer的写入会返回错误。
+	pr, pw := io.Pipe()
+	go func() {
+		defer func() {
+			if x := recover(); x != nil {
+				logError(ctx, "gmvc: action %s stream panic: %v", actionName(ctx), x)
+				_ = pw.CloseWithError(&PanicError{Value: x})
+			}
+		}()
+
+		err := stream.Writer(pw)
+		if err != nil {
+			logError(ctx, "gmvc: action %s stream aborted: %v", actionName(ctx), err)
+		}
+
+		_ = pw.CloseWithError(err)
+	}()
+
+	ctx.HttpResponse().Body(pr)
+}
+
+// MediaTypes Stream的媒体类型由Action决定，不参与协商
+func (r *StreamResponsor) MediaTypes() []string {
+	return nil
+}
+
+// streamResponse converts the Stream to a Response rendered by [StreamResponsor].
+func streamResponse(stream *Stream) *Response {
+	return &Response{
+		StatusCode: stream.StatusCode,
+		Header:     stream.Header,
+		Render:     Streaming,
+		Body:       stream,
+	}
+}
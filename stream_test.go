@@ -0,0 +1,95 @@
+package gmvc
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type streamItem struct {
+	ID int `json:"id"`
+}
+
+type streamAction struct {
+	Kind string `param:"kind,Query"`
+}
+
+func (a *streamAction) Go() (interface{}, error) {
+	switch a.Kind {
+	case "reader":
+		return StreamReader("text/csv", strings.NewReader("a,b\n1,2\n")), nil
+	case "ndjson":
+		ch := make(chan streamItem)
+		go func() {
+			defer close(ch)
+			for i := 1; i <= 3; i++ {
+				ch <- streamItem{ID: i}
+			}
+		}()
+
+		return StreamNDJSON(ch), nil
+	case "array":
+		return StreamJSONArraySeq(func(yield func(streamItem) bool) {
+			for i := 1; i <= 3; i++ {
+				if !yield(streamItem{ID: i}) {
+					return
+				}
+			}
+		}), nil
+	case "empty":
+		return StreamJSONArraySeq(func(yield func(streamItem) bool) {}), nil
+	case "abort":
+		return StreamWriter("text/plain", func(w io.Writer) error {
+			_, _ = io.WriteString(w, "partial")
+			return errors.New("boom")
+		}), nil
+	}
+
+	return nil, nil
+}
+
+func TestStream(t *testing.T) {
+	builder := CreateGmvcBuilder()
+
+	cases := []struct {
+		kind        string
+		contentType string
+		want        string
+	}{
+		{kind: "reader", contentType: "text/csv", want: "a,b\n1,2"},
+		{kind: "ndjson", contentType: "application/x-ndjson", want: "{\"id\":1}\n{\"id\":2}\n{\"id\":3}"},
+		{kind: "array", contentType: "application/json", want: `[{"id":1},{"id":2},{"id":3}]`},
+		{kind: "empty", contentType: "application/json", want: `[]`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.kind, func(t *testing.T) {
+			ctx := newMockContext(http.MethodGet, "/stream?kind="+c.kind)
+			serve(builder, &streamAction{}, ctx)
+
+			assert.Equal(t, http.StatusOK, ctx.resp.status)
+			contentType, _ := ctx.resp.header.Get("Content-Type")
+			assert.Equal(t, c.contentType, contentType)
+			assert.Equal(t, c.want, bodyString(ctx))
+		})
+	}
+}
+
+func TestStreamAbort(t *testing.T) {
+	log := &recordLogger{}
+	SetLogger(log)
+	defer SetLogger(nil)
+
+	builder := CreateGmvcBuilder()
+	ctx := newMockContext(http.MethodGet, "/stream?kind=abort")
+	serve(builder, &streamAction{}, ctx)
+
+	// 已经写出的部分保留，错误被记录
+	assert.Equal(t, "partial", bodyString(ctx))
+	assert.Len(t, log.errors, 1)
+	assert.Contains(t, log.errors[0], "boom")
+}
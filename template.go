@@ -0,0 +1,181 @@
+package gmvc
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sync"
+)
+
+// TemplateConfig configures the templates of [TemplateResponsor].
+type TemplateConfig struct {
+	// FS contains the templates, eg. an embed.FS, or os.DirFS("templates") for Reload.
+	FS fs.FS
+
+	// Pages are the glob patterns of the page templates, eg. "pages/*.html".
+	// A page is rendered by its path in FS, eg. "pages/index.html".
+	Pages []string
+
+	// Shared are the glob patterns of the layouts and partials shared by every page, eg. "layouts/*.html".
+	// Templates defined in them can also be rendered by name, eg. a partial for an AJAX request.
+	Shared []string
+
+	// Layout is the template executed for every page, the page defines the blocks used by the layout.
+	// The page itself is executed if empty.
+	Layout string
+
+	// Funcs are added to the templates before parsing.
+	Funcs template.FuncMap
+
+	// Reload parses the templates on every render, for development only.
+	Reload bool
+}
+
+// templateSet is the parsed templates, each page is parsed into a clone of the shared templates,
+// so that pages can define the same blocks.
+type templateSet struct {
+	shared *template.Template
+	pages  map[string]*template.Template
+}
+
+var _ Responsor = (*TemplateResponsor)(nil)
+
+// TemplateResponsor html/template实现，Response.Body为模板名称，Response.Model为模板数据。
+// 不依赖底层框架，可以替换默认的HTMLResponsor：
+//
+//	builder.RegisterResponsor(gmvc.HTML, responsor)
+type TemplateResponsor struct {
+	config TemplateConfig
+
+	mu  sync.RWMutex
+	set *templateSet
+}
+
+// NewTemplateResponsor parses the templates, an error is returned if any template is malformed.
+func NewTemplateResponsor(config TemplateConfig) (*TemplateResponsor, error) {
+	if config.FS == nil {
+		return nil, fmt.Errorf("gmvc: TemplateConfig.FS is required")
+	}
+
+	set, err := parseTemplates(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TemplateResponsor{config: config, set: set}, nil
+}
+
+// Response 返回的方法
+func (r *TemplateResponsor) Response(ctx GmvcContext, resp *Response) {
+	setDefault(resp)
+	setHeader(ctx, resp)
+
+	name, ok := resp.Body.(string)
+	if !ok {
+		logError(ctx, "gmvc: action %s renders a template by %T, template name expected", actionName(ctx), resp.Body)
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	set, err := r.templates()
+	if err != nil {
+		logError(ctx, "gmvc: parse templates failed: %v", err)
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	// 渲染到buffer中，模板执行失败时不会返回残缺的页面
+	var buf bytes.Buffer
+	if err := set.execute(&buf, name, r.config.Layout, resp.Model); err != nil {
+		logError(ctx, "gmvc: action %s render template %s failed: %v", actionName(ctx), name, err)
+		ctx.HttpResponse().Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.HttpResponse().SetHeader("Content-Type", "text/html; charset=utf-8")
+	ctx.HttpResponse().Status(resp.StatusCode)
+	ctx.HttpResponse().Body(bytes.NewReader(buf.Bytes()))
+}
+
+// MediaTypes 协商用的媒体类型
+func (r *TemplateResponsor) MediaTypes() []string {
+	return []string{"text/html"}
+}
+
+func (r *TemplateResponsor) templates() (*templateSet, error) {
+	if !r.config.Reload {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.set, nil
+	}
+
+	set, err := parseTemplates(r.config)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.set = set
+	r.mu.Unlock()
+	return set, nil
+}
+
+func parseTemplates(config TemplateConfig) (*templateSet, error) {
+	shared := template.New("").Funcs(config.Funcs)
+	for _, pattern := range config.Shared {
+		if _, err := shared.ParseFS(config.FS, pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	set := &templateSet{shared: shared, pages: make(map[string]*template.Template)}
+	for _, pattern := range config.Pages {
+		paths, err := fs.Glob(config.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("gmvc: pattern matches no template: %s", pattern)
+		}
+
+		for _, path := range paths {
+			page, err := shared.Clone()
+			if err != nil {
+				return nil, err
+			}
+
+			b, err := fs.ReadFile(config.FS, path)
+			if err != nil {
+				return nil, err
+			}
+
+			// 以文件路径命名page，避免不同目录下的同名文件冲突
+			if _, err := page.New(path).Parse(string(b)); err != nil {
+				return nil, err
+			}
+
+			set.pages[path] = page
+		}
+	}
+
+	return set, nil
+}
+
+func (s *templateSet) execute(buf *bytes.Buffer, name, layout string, data interface{}) error {
+	if page, ok := s.pages[name]; ok {
+		if layout != "" {
+			return page.ExecuteTemplate(buf, layout, data)
+		}
+
+		return page.ExecuteTemplate(buf, name, data)
+	}
+
+	if s.shared.Lookup(name) != nil {
+		return s.shared.ExecuteTemplate(buf, name, data)
+	}
+
+	return fmt.Errorf("template %s not found", name)
+}
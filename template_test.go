@@ -0,0 +1,103 @@
+package gmvc
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type templateAction struct {
+	Page string `param:"page,Query"`
+}
+
+func (a *templateAction) Go() (interface{}, error) {
+	return &Response{
+		Render: HTML,
+		Body:   a.Page,
+		Model:  map[string]interface{}{"Name": "<gmvc>"},
+	}, nil
+}
+
+func templateFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`{{define "base"}}<title>{{template "title" .}}</title>{{template "content" .}}{{end}}`)},
+		"partials/user.html": {Data: []byte(`{{define "user"}}<b>{{upper .Name}}</b>{{end}}`)},
+		"pages/index.html":   {Data: []byte(`{{define "title"}}Index{{end}}{{define "content"}}Hello {{template "user" .}}{{end}}`)},
+		"pages/about.html":   {Data: []byte(`{{define "title"}}About{{end}}{{define "content"}}About {{.Name}}{{end}}`)},
+	}
+}
+
+func renderTemplate(t *testing.T, config TemplateConfig, page string) *mockContext {
+	responsor, err := NewTemplateResponsor(config)
+	assert.Nil(t, err)
+
+	builder := CreateGmvcBuilder()
+	builder.RegisterResponsor(HTML, responsor)
+
+	ctx := newMockContext(http.MethodGet, "/?page="+page)
+	serve(builder, &templateAction{}, ctx)
+	return ctx
+}
+
+func TestTemplateResponsor(t *testing.T) {
+	config := TemplateConfig{
+		FS:     templateFS(),
+		Pages:  []string{"pages/*.html"},
+		Shared: []string{"layouts/*.html", "partials/*.html"},
+		Layout: "base",
+		Funcs:  template.FuncMap{"upper": strings.ToUpper},
+	}
+
+	cases := []struct {
+		page   string
+		status int
+		want   string
+	}{
+		{page: "pages/index.html", status: http.StatusOK, want: `<title>Index</title>Hello <b>&lt;GMVC&gt;</b>`},
+		{page: "pages/about.html", status: http.StatusOK, want: `<title>About</title>About &lt;gmvc&gt;`},
+		{page: "user", status: http.StatusOK, want: `<b>&lt;GMVC&gt;</b>`},
+		{page: "pages/missing.html", status: http.StatusInternalServerError, want: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.page, func(t *testing.T) {
+			ctx := renderTemplate(t, config, c.page)
+			assert.Equal(t, c.status, ctx.resp.status)
+			assert.Equal(t, c.want, bodyString(ctx))
+			if c.status == http.StatusOK {
+				contentType, _ := ctx.resp.header.Get("Content-Type")
+				assert.Equal(t, "text/html; charset=utf-8", contentType)
+			}
+		})
+	}
+}
+
+func TestTemplateResponsorReload(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte(`v1`)}}
+	responsor, err := NewTemplateResponsor(TemplateConfig{FS: fsys, Pages: []string{"*.html"}, Reload: true})
+	assert.Nil(t, err)
+
+	builder := CreateGmvcBuilder()
+	builder.RegisterResponsor(HTML, responsor)
+
+	ctx := newMockContext(http.MethodGet, "/?page=index.html")
+	serve(builder, &templateAction{}, ctx)
+	assert.Equal(t, "v1", bodyString(ctx))
+
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`v2`)}
+	ctx = newMockContext(http.MethodGet, "/?page=index.html")
+	serve(builder, &templateAction{}, ctx)
+	assert.Equal(t, "v2", bodyString(ctx))
+}
+
+func TestTemplateResponsorMalformed(t *testing.T) {
+	_, err := NewTemplateResponsor(TemplateConfig{
+		FS:    fstest.MapFS{"index.html": {Data: []byte(`{{if}}`)}},
+		Pages: []string{"*.html"},
+	})
+	assert.NotNil(t, err)
+}